@@ -42,3 +42,12 @@ const (
 	cborSigilIndefiniteMap         = 0xbf
 	cborSigilBreak                 = 0xff
 )
+
+// Tags with standard meanings which we handle as part of the value they tag,
+// rather than yielding them as a tag on the token.
+// See https://tools.ietf.org/html/rfc7049#section-2.4 .
+const (
+	cborTagPosBignum = 2
+	cborTagNegBignum = 3
+	cborTagBigfloat  = 5
+)
@@ -12,9 +12,14 @@ type Decoder struct {
 	cfg DecodeOptions
 	r   shared.SlickReader
 
-	stack []decoderPhase // When empty, and step returns done, all done.
-	phase decoderPhase   // Shortcut to end of stack.
-	left  []int          // Statekeeping space for definite-len map and array.
+	stack []decoderPhase             // When empty, and step returns done, all done.
+	phase decoderPhase               // Shortcut to end of stack.
+	left  []int                      // Statekeeping space for definite-len map and array.
+	keys  [][]byte                   // Serial form of the previous key in each definite-len map.  Only used in canonical mode.
+	seen  []map[interface{}]struct{} // Keys seen in each map we're within.  Only used if rejecting duplicate keys.
+
+	tokenStart int   // Offset of the first byte of the most recently yielded token.
+	pendingErr error // Error hit while peeking in More; returned by the next Step.
 }
 
 type decoderPhase uint8
@@ -44,11 +49,18 @@ func (d *Decoder) Reset() {
 	d.stack = d.stack[0:0]
 	d.phase = decoderPhase_acceptValue
 	d.left = d.left[0:0]
+	d.keys = d.keys[0:0]
+	d.seen = d.seen[0:0]
 }
 
 type decoderStep func(tokenSlot *Token) (done bool, err error)
 
 func (d *Decoder) Step(tokenSlot *Token) (done bool, err error) {
+	d.tokenStart = d.r.NumRead()
+	if d.pendingErr != nil {
+		err, d.pendingErr = d.pendingErr, nil
+		return true, d.syntaxError(err)
+	}
 	switch d.phase {
 	case decoderPhase_acceptValue:
 		done, err = d.step_acceptValue(tokenSlot)
@@ -67,7 +79,7 @@ func (d *Decoder) Step(tokenSlot *Token) (done bool, err error) {
 	}
 	// If the step errored: out, entirely.
 	if err != nil {
-		return true, err
+		return true, d.syntaxError(err)
 	}
 	// If the step wasn't done, return same status.
 	if !done {
@@ -83,6 +95,49 @@ func (d *Decoder) Step(tokenSlot *Token) (done bool, err error) {
 	return false, nil
 }
 
+/*
+	More reports whether there's another value in the input: that is,
+	whether the input hasn't yet ended.
+
+	Use it to decode a CBOR Sequence (RFC 8742), which is simply several
+	top-level values one after another: loop while More is true, calling
+	Reset before stepping through each value.  (The Unmarshaller does the
+	resetting.)
+
+	If reading fails, More reports true, and the error is returned by the
+	next Step.
+*/
+func (d *Decoder) More() bool {
+	_, err := d.r.Readn1()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		d.pendingErr = err
+		return true
+	}
+	d.r.Unreadn1()
+	return true
+}
+
+// Returns the byte offset in the input where the most recently yielded token began.
+func (d *Decoder) TokenOffset() int {
+	return d.tokenStart
+}
+
+// Wraps an error in ErrSyntax, saying where in the input the item we were decoding began.
+// EOF is left as is, as are errors which already carry their own position.
+func (d *Decoder) syntaxError(err error) error {
+	switch err.(type) {
+	case ErrNonCanonical, ErrDuplicateKey:
+		return err
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return err
+	}
+	return ErrSyntax{d.tokenStart, err}
+}
+
 func (d *Decoder) pushPhase(newPhase decoderPhase) {
 	d.stack = append(d.stack, d.phase)
 	d.phase = newPhase
@@ -125,11 +180,16 @@ func (d *Decoder) step_acceptMapIndefKey(tokenSlot *Token) (done bool, err error
 	tokenSlot.Tagged = false
 	switch majorByte {
 	case cborSigilBreak:
+		d.popSeen()
 		tokenSlot.Type = TMapClose
 		return true, nil
 	default:
 		d.phase = decoderPhase_acceptMapIndefValueOrBreak
+		offset := d.r.NumRead() - 1
 		_, err := d.stepHelper_acceptValue(majorByte, tokenSlot) // FIXME surely not *any* value?  not composites, at least?
+		if err == nil && d.cfg.RejectDuplicateKeys {
+			err = d.checkSeen(tokenSlot, offset)
+		}
 		return false, err
 	}
 }
@@ -177,18 +237,33 @@ func (d *Decoder) step_acceptMapKey(tokenSlot *Token) (done bool, err error) {
 	ll := len(d.left) - 1
 	if d.left[ll] == 0 {
 		d.left = d.left[0:ll]
+		if d.cfg.Canonical {
+			d.keys = d.keys[0 : len(d.keys)-1]
+		}
+		d.popSeen()
 		tokenSlot.Type = TMapClose
 		return true, nil
 	}
 	d.left[ll]--
 	// Read next key.
-	majorByte, err := d.r.Readn1()
-	if err != nil {
-		return true, err
+	offset := d.r.NumRead()
+	if d.cfg.Canonical {
+		d.phase = decoderPhase_acceptMapValue
+		tokenSlot.Tagged = false
+		err = d.stepHelper_acceptCanonicalKey(tokenSlot)
+	} else {
+		var majorByte byte
+		majorByte, err = d.r.Readn1()
+		if err != nil {
+			return true, err
+		}
+		d.phase = decoderPhase_acceptMapValue
+		tokenSlot.Tagged = false
+		_, err = d.stepHelper_acceptValue(majorByte, tokenSlot) // FIXME surely not *any* value?  not composites, at least?
+	}
+	if err == nil && d.cfg.RejectDuplicateKeys {
+		err = d.checkSeen(tokenSlot, offset)
 	}
-	d.phase = decoderPhase_acceptMapValue
-	tokenSlot.Tagged = false
-	_, err = d.stepHelper_acceptValue(majorByte, tokenSlot) // FIXME surely not *any* value?  not composites, at least?
 	return false, err
 }
 
@@ -206,6 +281,12 @@ func (d *Decoder) step_acceptMapValue(tokenSlot *Token) (done bool, err error) {
 }
 
 func (d *Decoder) stepHelper_acceptValue(majorByte byte, tokenSlot *Token) (done bool, err error) {
+	if d.cfg.Canonical {
+		switch majorByte {
+		case cborSigilIndefiniteBytes, cborSigilIndefiniteString, cborSigilIndefiniteArray, cborSigilIndefiniteMap:
+			return true, ErrNonCanonical{d.r.NumRead() - 1, "indefinite-length item"}
+		}
+	}
 	switch majorByte {
 	case cborSigilNil:
 		tokenSlot.Type = TNull
@@ -244,6 +325,7 @@ func (d *Decoder) stepHelper_acceptValue(majorByte byte, tokenSlot *Token) (done
 	case cborSigilIndefiniteMap:
 		tokenSlot.Type = TMapOpen
 		tokenSlot.Length = -1
+		d.pushSeen()
 		d.pushPhase(decoderPhase_acceptMapIndefKey)
 		return false, nil
 	default:
@@ -253,9 +335,7 @@ func (d *Decoder) stepHelper_acceptValue(majorByte byte, tokenSlot *Token) (done
 			tokenSlot.Uint, err = d.decodeUint(majorByte)
 			return true, err
 		case majorByte >= cborMajorNegInt && majorByte < cborMajorBytes:
-			tokenSlot.Type = TInt
-			tokenSlot.Int, err = d.decodeNegInt(majorByte)
-			return true, err
+			return true, d.decodeNegInt(majorByte, tokenSlot)
 		case majorByte >= cborMajorBytes && majorByte < cborMajorString:
 			tokenSlot.Type = TBytes
 			tokenSlot.Bytes, err = d.decodeBytes(majorByte)
@@ -278,6 +358,10 @@ func (d *Decoder) stepHelper_acceptValue(majorByte byte, tokenSlot *Token) (done
 			tokenSlot.Type = TMapOpen
 			tokenSlot.Length = n
 			d.left = append(d.left, n)
+			if d.cfg.Canonical {
+				d.pushKeys()
+			}
+			d.pushSeen()
 			d.pushPhase(decoderPhase_acceptMapKey)
 			return false, err
 		case majorByte >= cborMajorTag && majorByte < cborMajorSimple:
@@ -295,6 +379,18 @@ func (d *Decoder) stepHelper_acceptValue(majorByte byte, tokenSlot *Token) (done
 			if err != nil {
 				return true, err
 			}
+			// Bignum and bigfloat tags are part of the number, not a tag on it.
+			switch tokenSlot.Tag {
+			case cborTagPosBignum, cborTagNegBignum:
+				tag := tokenSlot.Tag
+				tokenSlot.Tagged = false
+				tokenSlot.Tag = 0
+				return true, d.decodeBignum(tag, tokenSlot)
+			case cborTagBigfloat:
+				tokenSlot.Tagged = false
+				tokenSlot.Tag = 0
+				return true, d.decodeBigfloat(tokenSlot)
+			}
 			// Okay, we slurped a tag.
 			// Read next value.
 			majorByte, err := d.r.Readn1()
@@ -307,3 +403,81 @@ func (d *Decoder) stepHelper_acceptValue(majorByte byte, tokenSlot *Token) (done
 		}
 	}
 }
+
+// Pushes a fresh set for the keys of a map we're entering, if rejecting duplicate keys.
+func (d *Decoder) pushSeen() {
+	if !d.cfg.RejectDuplicateKeys {
+		return
+	}
+	sl := len(d.seen)
+	if sl < cap(d.seen) {
+		d.seen = d.seen[:sl+1]
+		for k := range d.seen[sl] {
+			delete(d.seen[sl], k)
+		}
+	} else {
+		d.seen = append(d.seen, map[interface{}]struct{}{})
+	}
+}
+
+// Pops the set of keys for a map we're leaving, if rejecting duplicate keys.
+func (d *Decoder) popSeen() {
+	if !d.cfg.RejectDuplicateKeys {
+		return
+	}
+	d.seen = d.seen[0 : len(d.seen)-1]
+}
+
+// Records a key in the set for the current map, erroring if it's already there.
+// Composite keys aren't checked.
+func (d *Decoder) checkSeen(tokenSlot *Token, offset int) error {
+	var k interface{}
+	switch tokenSlot.Type {
+	case TString:
+		k = tokenSlot.Str
+	case TInt:
+		k = tokenSlot.Int
+	case TUint:
+		k = tokenSlot.Uint
+	case TBigInt:
+		// Bignums may be used for small values too, so compare those as plain ints.
+		switch bi := tokenSlot.BigInt; {
+		case bi.IsUint64():
+			k = bi.Uint64()
+		case bi.IsInt64():
+			k = bi.Int64()
+		default:
+			k = bigIntKey(bi.String())
+		}
+	case TBytes:
+		k = bytesKey(tokenSlot.Bytes)
+	case TBool:
+		k = tokenSlot.Bool
+	case TFloat64:
+		k = tokenSlot.Float64
+	case TNull:
+		k = TNull
+	default:
+		return nil
+	}
+	seen := d.seen[len(d.seen)-1]
+	if _, exists := seen[k]; exists {
+		switch k2 := k.(type) {
+		case bytesKey:
+			k = []byte(k2)
+		case bigIntKey:
+			k = tokenSlot.BigInt
+		}
+		return ErrDuplicateKey{k, offset}
+	}
+	seen[k] = struct{}{}
+	return nil
+}
+
+// Byte string keys are stored as this in the set of seen keys, since slices aren't comparable.
+// (It's a distinct type so they can't collide with text string keys.)
+type bytesKey string
+
+// Big int keys too large for the plain int types are stored as this in the set of seen keys,
+// by their decimal form, since pointers don't compare by value.
+type bigIntKey string
@@ -0,0 +1,95 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"math"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Checks that the header just read used the shortest form for its argument.
+// Offset is the position of the header's major byte.
+func (d *Decoder) checkCanonicalUint(offset int, v byte, ui uint64) error {
+	var min uint64
+	switch v {
+	case 0x18:
+		min = 0x18
+	case 0x19:
+		min = 0xff + 1
+	case 0x1a:
+		min = 0xffff + 1
+	case 0x1b:
+		min = 0xffffffff + 1
+	default:
+		return nil
+	}
+	if ui < min {
+		return ErrNonCanonical{offset, "integer or length header is not in shortest form"}
+	}
+	return nil
+}
+
+// Checks that the float just read used the shortest precision that can represent it.
+// Offset is the position of the float's major byte.
+func (d *Decoder) checkCanonicalFloat(offset int, majorByte byte, bs []byte, f float64) error {
+	if math.IsNaN(f) {
+		if majorByte != cborSigilFloat16 || binary.BigEndian.Uint16(bs) != 0x7e00 {
+			return ErrNonCanonical{offset, "NaN is not encoded as half-precision 0x7e00"}
+		}
+		return nil
+	}
+	switch majorByte {
+	case cborSigilFloat32:
+		if _, ok := float16FromFloat64(f); ok {
+			return ErrNonCanonical{offset, "float is not in shortest form"}
+		}
+	case cborSigilFloat64:
+		if _, ok := float16FromFloat64(f); ok || float64(float32(f)) == f {
+			return ErrNonCanonical{offset, "float is not in shortest form"}
+		}
+	}
+	return nil
+}
+
+// Reads a key in a definite-length map, checking that it sorts strictly after the previous key.
+// Used in place of the normal value step when decoding in canonical mode.
+func (d *Decoder) stepHelper_acceptCanonicalKey(tokenSlot *Token) error {
+	offset := d.r.NumRead()
+	d.r.Track()
+	majorByte, err := d.r.Readn1()
+	if err != nil {
+		d.r.StopTrack()
+		return err
+	}
+	_, err = d.stepHelper_acceptValue(majorByte, tokenSlot)
+	key := d.r.StopTrack()
+	if err != nil {
+		return err
+	}
+	switch tokenSlot.Type {
+	case TMapOpen, TArrOpen:
+		return ErrNonCanonical{offset, "map key is not a scalar"}
+	}
+	kl := len(d.keys) - 1
+	if prev := d.keys[kl]; len(prev) > 0 {
+		switch cmp := compareKeys(prev, key); {
+		case cmp == 0:
+			return ErrNonCanonical{offset, "repeated map key"}
+		case cmp > 0:
+			return ErrNonCanonical{offset, "map keys are not in canonical order"}
+		}
+	}
+	d.keys[kl] = append(d.keys[kl][:0], key...)
+	return nil
+}
+
+// Pushes fresh space for remembering the previous key of a definite-length map.
+func (d *Decoder) pushKeys() {
+	kl := len(d.keys)
+	if kl < cap(d.keys) {
+		d.keys = d.keys[:kl+1]
+		d.keys[kl] = d.keys[kl][:0]
+	} else {
+		d.keys = append(d.keys, nil)
+	}
+}
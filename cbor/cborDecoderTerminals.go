@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
+
+	. "github.com/polydawn/refmt/tok"
 )
 
 const (
@@ -25,6 +28,9 @@ func (d *Decoder) decodeFloat(majorByte byte) (f float64, err error) {
 		bs, err = d.r.Readnzc(8)
 		f = math.Float64frombits(binary.BigEndian.Uint64(bs))
 	}
+	if err == nil && d.cfg.Canonical {
+		err = d.checkCanonicalFloat(d.r.NumRead()-len(bs)-1, majorByte, bs, f)
+	}
 	return
 }
 
@@ -32,6 +38,7 @@ func (d *Decoder) decodeFloat(majorByte byte) (f float64, err error) {
 // Must continue to hand down the majorByte because some of its bits are either
 // packed with the value outright, or tell us how many more bytes the value fills.
 func (d *Decoder) decodeUint(majorByte byte) (ui uint64, err error) {
+	offset := d.r.NumRead() - 1 // position of the majorByte, for error reporting.
 	v := majorByte & 0x1f
 	if v <= 0x17 {
 		ui = uint64(v)
@@ -56,6 +63,9 @@ func (d *Decoder) decodeUint(majorByte byte) (ui uint64, err error) {
 			err = fmt.Errorf("decodeUint: Invalid descriptor: %v", majorByte)
 			return
 		}
+		if err == nil && d.cfg.Canonical {
+			err = d.checkCanonicalUint(offset, v, ui)
+		}
 	}
 	return
 }
@@ -65,19 +75,25 @@ func (d *Decoder) decodeUint(majorByte byte) (ui uint64, err error) {
 // and there is explicitly negative signed int... and there is no signed, positive int.
 // *We have no 'decodeInt' function because that **doesn't exist** in CBOR.*
 // So!  Hopefully our consumer doesn't mind having to cast uints to ints fairly frequently.
-func (d *Decoder) decodeNegInt(majorByte byte) (i int64, err error) {
+//
+// CBOR negative ints reach down to -2^64, which is beyond the range of int64;
+// those few are yielded as a TBigInt.
+func (d *Decoder) decodeNegInt(majorByte byte, tokenSlot *Token) error {
 	// The packed bits in the majorByte and the following bytes if any are layed out
 	// the exact same as a uint; only the major type bits are different.
 	ui, err := d.decodeUint(majorByte)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	pos := ui + 1
-	if pos > uint64(-math.MinInt64) {
-		return -1, errors.New("cbor: negative integer out of rage of int64 type")
+	if ui <= math.MaxInt64 {
+		tokenSlot.Type = TInt
+		tokenSlot.Int = -1 - int64(ui)
+		return nil
 	}
-
-	return -int64(pos), nil
+	bi := new(big.Int).SetUint64(ui)
+	tokenSlot.Type = TBigInt
+	tokenSlot.BigInt = bi.Not(bi) // -1-n
+	return nil
 }
 
 // Decode expecting a positive integer.
@@ -202,6 +218,125 @@ func (d *Decoder) decodeString(majorByte byte) (s string, err error) {
 	return string(bs), err
 }
 
+// Decode the content of a bignum tag (2 for positive, 3 for negative),
+// the tag itself having already been read.
+// The content is a byte string holding the big-endian magnitude.
+func (d *Decoder) decodeBignum(tag int, tokenSlot *Token) error {
+	offset := d.r.NumRead()
+	majorByte, err := d.r.Readn1()
+	if err != nil {
+		return err
+	}
+	var bs []byte
+	switch {
+	case majorByte == cborSigilIndefiniteBytes && !d.cfg.Canonical:
+		bs, err = d.decodeBytesIndefinite(nil)
+	case majorByte >= cborMajorBytes && majorByte < cborMajorString:
+		bs, err = d.decodeBytes(majorByte)
+	default:
+		return fmt.Errorf("cbor: bignum tag %d must be followed by a byte string; got major byte 0x%x", tag, majorByte)
+	}
+	if err != nil {
+		return err
+	}
+	if d.cfg.Canonical {
+		switch {
+		case len(bs) > 0 && bs[0] == 0:
+			return ErrNonCanonical{offset, "bignum has leading zero bytes"}
+		case len(bs) <= 8:
+			return ErrNonCanonical{offset, "bignum fits in a plain integer"}
+		}
+	}
+	bi := new(big.Int).SetBytes(bs)
+	if tag == cborTagNegBignum {
+		bi.Not(bi) // -1-n
+	}
+	tokenSlot.Type = TBigInt
+	tokenSlot.BigInt = bi
+	return nil
+}
+
+// Decode the content of a bigfloat tag (5), the tag itself having already been read.
+// The content is a two-element array of an integer exponent and an integer
+// (or bignum) mantissa; the value is mantissa*2^exponent.
+func (d *Decoder) decodeBigfloat(tokenSlot *Token) error {
+	offset := d.r.NumRead()
+	majorByte, err := d.r.Readn1()
+	if err != nil {
+		return err
+	}
+	if majorByte != cborMajorArray|2 {
+		return fmt.Errorf("cbor: bigfloat tag must be followed by an array of exponent and mantissa; got major byte 0x%x", majorByte)
+	}
+	// Read the exponent.  It has to fit in an int32 to be meaningful to big.Float.
+	majorByte, err = d.r.Readn1()
+	if err != nil {
+		return err
+	}
+	var exp int64
+	switch {
+	case majorByte >= cborMajorUint && majorByte < cborMajorNegInt:
+		var ui uint64
+		ui, err = d.decodeUint(majorByte)
+		exp = int64(ui)
+		if ui > math.MaxInt32 {
+			exp = math.MaxInt32 + 1
+		}
+	case majorByte >= cborMajorNegInt && majorByte < cborMajorBytes:
+		var ui uint64
+		ui, err = d.decodeUint(majorByte)
+		exp = -1 - int64(ui)
+		if ui > math.MaxInt32 {
+			exp = math.MinInt32 - 1
+		}
+	default:
+		return fmt.Errorf("cbor: bigfloat exponent must be an integer; got major byte 0x%x", majorByte)
+	}
+	if err != nil {
+		return err
+	}
+	if exp < math.MinInt32 || exp > math.MaxInt32 {
+		return fmt.Errorf("cbor: bigfloat exponent is out of range")
+	}
+	// Read the mantissa.
+	majorByte, err = d.r.Readn1()
+	if err != nil {
+		return err
+	}
+	mant := new(big.Int)
+	switch {
+	case majorByte >= cborMajorUint && majorByte < cborMajorNegInt:
+		var ui uint64
+		ui, err = d.decodeUint(majorByte)
+		mant.SetUint64(ui)
+	case majorByte >= cborMajorNegInt && majorByte < cborMajorBytes:
+		err = d.decodeNegInt(majorByte, tokenSlot)
+		if tokenSlot.Type == TInt {
+			mant.SetInt64(tokenSlot.Int)
+		} else {
+			mant = tokenSlot.BigInt
+		}
+	case majorByte == cborMajorTag|cborTagPosBignum, majorByte == cborMajorTag|cborTagNegBignum:
+		err = d.decodeBignum(int(majorByte&0x1f), tokenSlot)
+		mant = tokenSlot.BigInt
+	default:
+		return fmt.Errorf("cbor: bigfloat mantissa must be an integer or bignum; got major byte 0x%x", majorByte)
+	}
+	if err != nil {
+		return err
+	}
+	bf := new(big.Float).SetInt(mant)
+	bf.SetMantExp(bf, int(exp))
+	if d.cfg.Canonical {
+		if f, acc := bf.Float64(); acc == big.Exact && !math.IsInf(f, 0) {
+			return ErrNonCanonical{offset, "bigfloat is exactly representable as a float"}
+		}
+	}
+	tokenSlot.Type = TBigFloat
+	tokenSlot.BigFloat = bf
+	return nil
+}
+
 // culled from OGRE (Object-Oriented Graphics Rendering Engine)
 // function: halfToFloatI (http://stderr.org/doc/ogre-doc/api/OgreBitwise_8h-source.html)
 func halfFloatToFloatBits(yy uint16) (d uint32) {
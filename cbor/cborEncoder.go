@@ -7,28 +7,40 @@ import (
 )
 
 type Encoder struct {
-	w quickWriter
+	w   quickWriter // Current destination: either the stream, or a buffer, if we're inside a map or array that must be buffered.
+	out quickWriter // The stream.
+	cfg EncodeOptions
 
 	stack   []encoderPhase // When empty, and step returns done, all done.
 	current encoderPhase   // Shortcut to end of stack.
 	// Note unlike decoder, we need no statekeeping space for definite-len map and array.
 
+	// Buffers for maps and arrays which can't be written out until they're complete
+	// (only used by some of the canonical encoding options).
+	// Frames are retained when popped, so their buffers can be reused.
+	frames  []*encoderFrame
+	nFrames int
+
 	spareBytes []byte
 }
 
-func NewEncoder(w io.Writer) (d *Encoder) {
+func NewEncoder(w io.Writer, cfg EncodeOptions) (d *Encoder) {
 	d = &Encoder{
-		w:          newQuickWriterStream(w),
+		out:        newQuickWriterStream(w),
+		cfg:        cfg,
 		stack:      make([]encoderPhase, 0, 10),
 		current:    phase_anyExpectValue,
 		spareBytes: make([]byte, 8),
 	}
+	d.w = d.out
 	return
 }
 
 func (d *Encoder) Reset() {
 	d.stack = d.stack[0:0]
 	d.current = phase_anyExpectValue
+	d.nFrames = 0
+	d.w = d.out
 }
 
 type encoderPhase byte
@@ -72,6 +84,18 @@ func (d *Encoder) Step(tokenSlot *Token) (done bool, err error) {
 		far the shorter volume of code to write.
 	*/
 	phase := d.current
+	if d.nFrames > 0 {
+		d.markEntry(tokenSlot)
+	}
+	// Numbers kept as text get converted to whichever form fits them.
+	//  (Work on a copy: the token isn't ours to modify.)
+	if tokenSlot.Type == TNumber {
+		normalized := *tokenSlot
+		if err := NormalizeNumber(&normalized); err != nil {
+			return true, err
+		}
+		tokenSlot = &normalized
+	}
 	switch tokenSlot.Type {
 	case TMapOpen:
 		switch phase {
@@ -82,7 +106,10 @@ func (d *Encoder) Step(tokenSlot *Token) (done bool, err error) {
 			if tokenSlot.Tagged {
 				d.emitMajorPlusLen(cborMajorTag, uint64(tokenSlot.Tag))
 			}
-			if tokenSlot.Length >= 0 {
+			if d.cfg.SortMapKeys || (d.cfg.DefiniteLengths && tokenSlot.Length < 0) {
+				d.pushPhase(phase_mapIndefExpectKeyOrEnd)
+				d.openFrame(true, tokenSlot.Length < 0 && !d.cfg.DefiniteLengths)
+			} else if tokenSlot.Length >= 0 {
 				d.pushPhase(phase_mapDefExpectKeyOrEnd)
 				d.emitMajorPlusLen(cborMajorMap, uint64(tokenSlot.Length))
 			} else {
@@ -100,6 +127,12 @@ func (d *Encoder) Step(tokenSlot *Token) (done bool, err error) {
 		case phase_mapDefExpectKeyOrEnd:
 			return d.popPhase(), nil
 		case phase_mapIndefExpectKeyOrEnd:
+			if d.isFrameCurrent() {
+				if err := d.closeFrame(); err != nil {
+					return true, err
+				}
+				return d.popPhase(), d.w.checkErr()
+			}
 			d.w.writen1(cborSigilBreak)
 			return d.popPhase(), d.w.checkErr()
 		case phase_anyExpectValue, phase_mapDefExpectValue, phase_mapIndefExpectValue, phase_arrDefExpectValueOrEnd, phase_arrIndefExpectValueOrEnd:
@@ -116,7 +149,10 @@ func (d *Encoder) Step(tokenSlot *Token) (done bool, err error) {
 			if tokenSlot.Tagged {
 				d.emitMajorPlusLen(cborMajorTag, uint64(tokenSlot.Tag))
 			}
-			if tokenSlot.Length >= 0 {
+			if d.cfg.DefiniteLengths && tokenSlot.Length < 0 {
+				d.pushPhase(phase_arrIndefExpectValueOrEnd)
+				d.openFrame(false, false)
+			} else if tokenSlot.Length >= 0 {
 				d.pushPhase(phase_arrDefExpectValueOrEnd)
 				d.emitMajorPlusLen(cborMajorArray, uint64(tokenSlot.Length))
 			} else {
@@ -134,6 +170,12 @@ func (d *Encoder) Step(tokenSlot *Token) (done bool, err error) {
 		case phase_arrDefExpectValueOrEnd:
 			return d.popPhase(), nil
 		case phase_arrIndefExpectValueOrEnd:
+			if d.isFrameCurrent() {
+				if err := d.closeFrame(); err != nil {
+					return true, err
+				}
+				return d.popPhase(), d.w.checkErr()
+			}
 			d.w.writen1(cborSigilBreak)
 			return d.popPhase(), d.w.checkErr()
 		case phase_anyExpectValue, phase_mapDefExpectValue, phase_mapIndefExpectValue:
@@ -270,6 +312,43 @@ func (d *Encoder) Step(tokenSlot *Token) (done bool, err error) {
 		default:
 			panic("unreachable phase")
 		}
+	case TBigInt: // terminal value; YES, accepted as map key.
+		switch phase {
+		case phase_mapDefExpectValue, phase_mapIndefExpectValue:
+			d.current -= 1
+			fallthrough
+		case phase_anyExpectValue, phase_arrDefExpectValueOrEnd, phase_arrIndefExpectValueOrEnd:
+			goto emitBigInt
+		case phase_mapDefExpectKeyOrEnd, phase_mapIndefExpectKeyOrEnd:
+			d.current += 1
+			goto emitBigInt
+		default:
+			panic("unreachable phase")
+		}
+	emitBigInt:
+		{
+			if tokenSlot.Tagged {
+				d.emitMajorPlusLen(cborMajorTag, uint64(tokenSlot.Tag))
+			}
+			d.encodeBigInt(tokenSlot.BigInt)
+			return phase == phase_anyExpectValue, d.w.checkErr()
+		}
+	case TBigFloat: // terminal value; not accepted as map key.
+		switch phase {
+		case phase_mapDefExpectValue, phase_mapIndefExpectValue:
+			d.current -= 1
+			fallthrough
+		case phase_anyExpectValue, phase_arrDefExpectValueOrEnd, phase_arrIndefExpectValueOrEnd:
+			if tokenSlot.Tagged {
+				d.emitMajorPlusLen(cborMajorTag, uint64(tokenSlot.Tag))
+			}
+			d.encodeBigFloat(tokenSlot.BigFloat)
+			return phase == phase_anyExpectValue, d.w.checkErr()
+		case phase_mapDefExpectKeyOrEnd, phase_mapIndefExpectKeyOrEnd:
+			return true, &ErrInvalidTokenStream{Got: *tokenSlot, Acceptable: tokenTypesForKey}
+		default:
+			panic("unreachable phase")
+		}
 	default:
 		panic("unhandled token type")
 	}
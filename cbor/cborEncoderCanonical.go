@@ -0,0 +1,179 @@
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// encoderFrame holds the content of a map or array which is being buffered
+// so that it can be emitted with a definite length, or with its entries sorted.
+type encoderFrame struct {
+	parent     quickWriter // Where to emit the content when done.
+	buf        bytes.Buffer
+	w          *quickWriterStream // Writes to buf.
+	depth      int                // Length of the encoder's phase stack while directly within this frame.
+	isMap      bool
+	indefinite bool // If true, emit with indefinite length anyway (we only buffered in order to sort).
+
+	count   int            // Number of entries (arrays only; maps use len(starts)).
+	starts  []int          // Offsets in buf where each map entry begins.
+	keyEnds []int          // Offsets in buf where each map entry's key ends.
+	entries []encoderEntry // Scratch space for sorting.
+}
+
+type encoderEntry struct {
+	key   []byte // The serial form of the key.
+	whole []byte // The serial form of the key and value together.
+}
+
+// Start buffering a map or array.
+// Call after pushing the phase for it.
+func (d *Encoder) openFrame(isMap bool, indefinite bool) {
+	if d.nFrames == len(d.frames) {
+		frame := &encoderFrame{}
+		frame.w = newQuickWriterStream(&frame.buf)
+		d.frames = append(d.frames, frame)
+	}
+	frame := d.frames[d.nFrames]
+	d.nFrames++
+	frame.parent = d.w
+	frame.buf.Reset()
+	frame.depth = len(d.stack)
+	frame.isMap = isMap
+	frame.indefinite = indefinite
+	frame.count = 0
+	frame.starts = frame.starts[:0]
+	frame.keyEnds = frame.keyEnds[:0]
+	d.w = frame.w
+}
+
+func (d *Encoder) isFrameCurrent() bool {
+	return d.nFrames > 0 && d.frames[d.nFrames-1].depth == len(d.stack)
+}
+
+// Take note of where entries begin, if we're directly within a buffered map or array.
+// Call before handling the token.
+func (d *Encoder) markEntry(tokenSlot *Token) {
+	if !d.isFrameCurrent() {
+		return
+	}
+	frame := d.frames[d.nFrames-1]
+	switch d.current {
+	case phase_mapIndefExpectKeyOrEnd:
+		if tokenSlot.Type != TMapClose {
+			frame.starts = append(frame.starts, frame.buf.Len())
+		}
+	case phase_mapIndefExpectValue:
+		frame.keyEnds = append(frame.keyEnds, frame.buf.Len())
+	case phase_arrIndefExpectValueOrEnd:
+		if tokenSlot.Type != TArrClose {
+			frame.count++
+		}
+	}
+}
+
+// Finish buffering a map or array, and emit it to the enclosing writer.
+// Call before popping the phase for it.
+func (d *Encoder) closeFrame() error {
+	d.nFrames--
+	frame := d.frames[d.nFrames]
+	d.w = frame.parent
+	content := frame.buf.Bytes()
+
+	if !frame.isMap {
+		d.emitMajorPlusLen(cborMajorArray, uint64(frame.count))
+		d.w.writeb(content)
+		return nil
+	}
+
+	frame.entries = frame.entries[:0]
+	for i, start := range frame.starts {
+		end := len(content)
+		if i+1 < len(frame.starts) {
+			end = frame.starts[i+1]
+		}
+		frame.entries = append(frame.entries, encoderEntry{
+			key:   content[start:frame.keyEnds[i]],
+			whole: content[start:end],
+		})
+	}
+	if d.cfg.SortMapKeys {
+		// RFC7049 canonical order: shorter keys first; then bytewise.
+		sort.Slice(frame.entries, func(i, j int) bool {
+			return compareKeys(frame.entries[i].key, frame.entries[j].key) < 0
+		})
+		for i := 1; i < len(frame.entries); i++ {
+			if bytes.Equal(frame.entries[i-1].key, frame.entries[i].key) {
+				return fmt.Errorf("cannot encode canonically: repeated map key (cbor: % x)", frame.entries[i].key)
+			}
+		}
+	}
+	if frame.indefinite {
+		d.w.writen1(cborSigilIndefiniteMap)
+	} else {
+		d.emitMajorPlusLen(cborMajorMap, uint64(len(frame.entries)))
+	}
+	for _, ent := range frame.entries {
+		d.w.writeb(ent.whole)
+	}
+	if frame.indefinite {
+		d.w.writen1(cborSigilBreak)
+	}
+	return nil
+}
+
+// Compares the serial forms of two map keys in RFC7049 canonical order:
+// shorter keys first; then bytewise.
+func compareKeys(a, b []byte) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a, b)
+}
+
+// Returns the IEEE 754 half-precision bits for the value,
+// and false if the value cannot be represented exactly in half-precision.
+// NaNs are all reported as the canonical quiet NaN.
+func float16FromFloat64(v float64) (uint16, bool) {
+	if math.IsNaN(v) {
+		return 0x7e00, true
+	}
+	f32 := float32(v)
+	if float64(f32) != v {
+		return 0, false
+	}
+	bits := math.Float32bits(f32)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+	switch {
+	case exp == 0xff: // infinity (NaN was handled above).
+		return sign | 0x7c00, true
+	case exp == 0 && mant == 0: // zero.
+		return sign, true
+	}
+	e := exp - 127
+	switch {
+	case e >= -14 && e <= 15: // normal range for half-precision.
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(e+15)<<10 | uint16(mant>>13), true
+	case e >= -24 && e < -14: // subnormal range for half-precision.
+		full := mant | 0x800000
+		shift := uint(-e - 1)
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	default:
+		return 0, false
+	}
+}
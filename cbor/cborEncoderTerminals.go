@@ -3,6 +3,7 @@ package cbor
 import (
 	"encoding/binary"
 	"math"
+	"math/big"
 )
 
 func (d *Encoder) emitLen(majorByte byte, length int) {
@@ -66,10 +67,67 @@ func (d *Encoder) encodeUint64(v uint64) {
 	d.emitMajorPlusLen(cborMajorUint, v)
 }
 
+// Emits a plain integer if the value fits in one (which is anything down to -2^64),
+// and a bignum tag otherwise.
+func (d *Encoder) encodeBigInt(v *big.Int) {
+	if v.Sign() >= 0 {
+		if v.IsUint64() {
+			d.emitMajorPlusLen(cborMajorUint, v.Uint64())
+			return
+		}
+		d.emitMajorPlusLen(cborMajorTag, cborTagPosBignum)
+		d.encodeBytes(v.Bytes())
+		return
+	}
+	n := new(big.Int).Not(v) // -1-v
+	if n.IsUint64() {
+		d.emitMajorPlusLen(cborMajorNegInt, n.Uint64())
+		return
+	}
+	d.emitMajorPlusLen(cborMajorTag, cborTagNegBignum)
+	d.encodeBytes(n.Bytes())
+}
+
+// Emits a plain float if the value is exactly representable as one,
+// and a bigfloat tag otherwise.
+func (d *Encoder) encodeBigFloat(v *big.Float) {
+	if f, acc := v.Float64(); acc == big.Exact {
+		d.encodeFloat64(f)
+		return
+	}
+	// Find the integer mantissa and exponent such that v is mant*2^exp.
+	mf := new(big.Float)
+	exp := v.MantExp(mf)
+	prec := int(v.MinPrec())
+	mf.SetMantExp(mf, prec)
+	mant, _ := mf.Int(nil)
+	d.emitMajorPlusLen(cborMajorTag, cborTagBigfloat)
+	d.emitLen(cborMajorArray, 2)
+	d.encodeInt64(int64(exp - prec))
+	d.encodeBigInt(mant)
+}
+
 func (d *Encoder) encodeFloat64(v float64) {
 	// Can we pack it into 32?  No idea: float precision is fraught with peril.
 	// See https://play.golang.org/p/u9sN6x0kk6
-	// So we *only* emit the full 64-bit style.  The CBOR spec permits this.
+	// So by default we *only* emit the full 64-bit style.  The CBOR spec permits this.
+	// If asked for the shortest encoding, we check carefully for exact round-trips.
+	if d.cfg.ShortestFloats {
+		if half, ok := float16FromFloat64(v); ok {
+			d.w.writen1(cborSigilFloat16)
+			d.spareBytes = d.spareBytes[:2]
+			binary.BigEndian.PutUint16(d.spareBytes, half)
+			d.w.writeb(d.spareBytes)
+			return
+		}
+		if f32 := float32(v); float64(f32) == v {
+			d.w.writen1(cborSigilFloat32)
+			d.spareBytes = d.spareBytes[:4]
+			binary.BigEndian.PutUint32(d.spareBytes, math.Float32bits(f32))
+			d.w.writeb(d.spareBytes)
+			return
+		}
+	}
 	d.w.writen1(cborSigilFloat64)
 	d.spareBytes = d.spareBytes[:8]
 	binary.BigEndian.PutUint64(d.spareBytes, math.Float64bits(v))
@@ -1,8 +1,10 @@
 package cbor
 
 import (
+	"fmt"
 	"testing"
 
+	. "github.com/polydawn/refmt/tok"
 	"github.com/polydawn/refmt/tok/fixtures"
 )
 
@@ -83,4 +85,10 @@ func testArray(t *testing.T) {
 			checkDecoding(t, seq, canon, nil)
 		})
 	})
+	t.Run("invalid entry reports position", func(t *testing.T) {
+		seq := fixtures.Sequence{"invalid entry", fixtures.Tokens{
+			{Type: TArrOpen, Length: 2}, {Type: TUint, Uint: 1}, {},
+		}}
+		checkDecoding(t, seq, bcat(b(0x80+2), b(0x01), b(0xfc)), ErrSyntax{2, fmt.Errorf("Invalid majorByte: 0xfc")})
+	})
 }
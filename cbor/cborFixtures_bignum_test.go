@@ -0,0 +1,121 @@
+package cbor
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func testBignum(t *testing.T) {
+	twoTo64 := new(big.Int).Lsh(big.NewInt(1), 64)
+	t.Run("bignum positive", func(t *testing.T) {
+		tok := Token{Type: TBigInt, BigInt: twoTo64}
+		canon := bcat(b(0xc0+2), b(0x40+9), b(0x01), make([]byte, 8))
+		t.Run("encode canonical", func(t *testing.T) {
+			checkEncoding(t, fixtures.Sequence{"", fixtures.Tokens{tok}}, canon, nil)
+		})
+		t.Run("decode canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, canon, nil)
+		})
+	})
+	t.Run("bignum negative", func(t *testing.T) {
+		tok := Token{Type: TBigInt, BigInt: new(big.Int).Not(twoTo64)} // -1-2^64
+		canon := bcat(b(0xc0+3), b(0x40+9), b(0x01), make([]byte, 8))
+		t.Run("encode canonical", func(t *testing.T) {
+			checkEncoding(t, fixtures.Sequence{"", fixtures.Tokens{tok}}, canon, nil)
+		})
+		t.Run("decode canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, canon, nil)
+		})
+	})
+	t.Run("negative integer beyond int64", func(t *testing.T) {
+		tok := Token{Type: TBigInt, BigInt: new(big.Int).Neg(twoTo64)}
+		canon := bcat(b(0x20+0x1b), bytes.Repeat([]byte{0xff}, 8))
+		t.Run("encode canonical", func(t *testing.T) {
+			checkEncoding(t, fixtures.Sequence{"", fixtures.Tokens{tok}}, canon, nil)
+		})
+		t.Run("decode canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, canon, nil)
+		})
+	})
+	t.Run("small bignum", func(t *testing.T) {
+		tok := Token{Type: TBigInt, BigInt: big.NewInt(5)}
+		serial := bcat(b(0xc0+2), b(0x40+1), b(0x05))
+		t.Run("encode", func(t *testing.T) {
+			checkEncoding(t, fixtures.Sequence{"", fixtures.Tokens{tok}}, b(0x05), nil)
+		})
+		t.Run("decode", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{}, tok, serial, nil)
+		})
+		t.Run("decode strictly canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, serial, ErrNonCanonical{1, "bignum fits in a plain integer"})
+		})
+	})
+	t.Run("bignum with leading zeros", func(t *testing.T) {
+		serial := bcat(b(0xc0+2), b(0x40+10), b(0x00), b(0x01), make([]byte, 8))
+		tok := Token{Type: TBigInt, BigInt: twoTo64}
+		t.Run("decode", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{}, tok, serial, nil)
+		})
+		t.Run("decode strictly canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, serial, ErrNonCanonical{1, "bignum has leading zero bytes"})
+		})
+	})
+	t.Run("bigfloat exact as float", func(t *testing.T) {
+		// The example from RFC7049 section 2.4.3: 1.5 as [-1, 3].
+		tok := Token{Type: TBigFloat, BigFloat: big.NewFloat(1.5)}
+		serial := bcat(b(0xc0+5), b(0x80+2), b(0x20+0), b(0x03))
+		t.Run("encode", func(t *testing.T) {
+			checkEncodingWithOptions(t, EncodeOptions{ShortestFloats: true}, fixtures.Sequence{"", fixtures.Tokens{tok}}, bcat(b(0xf9), b(0x3e), b(0x00)), nil)
+		})
+		t.Run("decode", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{}, tok, serial, nil)
+		})
+		t.Run("decode strictly canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, serial, ErrNonCanonical{1, "bigfloat is exactly representable as a float"})
+		})
+	})
+	t.Run("bigfloat too small for float", func(t *testing.T) {
+		tok := Token{Type: TBigFloat, BigFloat: new(big.Float).SetMantExp(big.NewFloat(1), -1100)}
+		canon := bcat(b(0xc0+5), b(0x80+2), b(0x20+0x19), []byte{0x04, 0x4b}, b(0x01))
+		t.Run("encode canonical", func(t *testing.T) {
+			checkEncoding(t, fixtures.Sequence{"", fixtures.Tokens{tok}}, canon, nil)
+		})
+		t.Run("decode canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, canon, nil)
+		})
+	})
+	t.Run("bigfloat too precise for float", func(t *testing.T) {
+		// (2^100+1) * 2^-100, which needs a bignum for its mantissa.
+		mant := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 100), big.NewInt(1))
+		tok := Token{Type: TBigFloat, BigFloat: new(big.Float).SetMantExp(new(big.Float).SetInt(mant), -100)}
+		canon := bcat(b(0xc0+5), b(0x80+2), b(0x20+0x18), b(99), b(0xc0+2), b(0x40+13), b(0x10), make([]byte, 11), b(0x01))
+		t.Run("encode canonical", func(t *testing.T) {
+			checkEncoding(t, fixtures.Sequence{"", fixtures.Tokens{tok}}, canon, nil)
+		})
+		t.Run("decode canonical", func(t *testing.T) {
+			checkBignumDecoding(t, DecodeOptions{Canonical: true}, tok, canon, nil)
+		})
+	})
+}
+
+// Decodes a single big number token.
+// The usual deep equality can't see into big numbers, so they're compared by value instead.
+func checkBignumDecoding(t *testing.T, cfg DecodeOptions, expect Token, serial []byte, expectErr error) {
+	t.Helper()
+	var slot Token
+	done, err := NewDecoder(cfg, bytes.NewReader(serial)).Step(&slot)
+	Wish(t, err, ShouldEqual, expectErr)
+	if expectErr != nil {
+		return
+	}
+	Wish(t, done, ShouldEqual, true)
+	if !IsTokenEqual(slot, expect) {
+		t.Errorf("decoded %s; expected %s", slot, expect)
+	}
+}
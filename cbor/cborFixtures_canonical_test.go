@@ -0,0 +1,181 @@
+package cbor
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func testCanonical(t *testing.T) {
+	t.Run("shortest floats", func(t *testing.T) {
+		cfg := EncodeOptions{ShortestFloats: true}
+		for _, tr := range []struct {
+			title  string
+			value  float64
+			serial []byte
+		}{
+			{"zero", 0, bcat(b(0xf9), b(0x00), b(0x00))},
+			{"negative zero", math.Copysign(0, -1), bcat(b(0xf9), b(0x80), b(0x00))},
+			{"one and a half", 1.5, bcat(b(0xf9), b(0x3e), b(0x00))},
+			{"largest half", 65504, bcat(b(0xf9), b(0x7b), b(0xff))},
+			{"smallest half subnormal", 5.960464477539063e-8, bcat(b(0xf9), b(0x00), b(0x01))},
+			{"infinity", math.Inf(1), bcat(b(0xf9), b(0x7c), b(0x00))},
+			{"nan", math.NaN(), bcat(b(0xf9), b(0x7e), b(0x00))},
+			{"needs single", 100000, bcat(b(0xfa), b(0x47), b(0xc3), b(0x50), b(0x00))},
+			{"needs double", 1.1, bcat(b(0xfb), b(0x3f), b(0xf1), b(0x99), b(0x99), b(0x99), b(0x99), b(0x99), b(0x9a))},
+		} {
+			t.Run(tr.title, func(t *testing.T) {
+				seq := fixtures.Sequence{tr.title, fixtures.Tokens{{Type: TFloat64, Float64: tr.value}}}
+				checkEncodingWithOptions(t, cfg, seq, tr.serial, nil)
+			})
+		}
+	})
+	t.Run("definite lengths", func(t *testing.T) {
+		// Every fixture, stripped of length info, should encode exactly
+		// the same as it does with length info.
+		cfg := EncodeOptions{DefiniteLengths: true}
+		for _, seq := range fixtures.Sequences {
+			switch seq.Title {
+			case "empty", "dangling arr open":
+				continue // These fixtures are deliberately incomplete.
+			}
+			t.Run(seq.Title, func(t *testing.T) {
+				checkEncodingWithOptions(t, cfg, seq.SansLengthInfo(), encodeFixture(t, seq), nil)
+			})
+		}
+	})
+	t.Run("sorted map keys", func(t *testing.T) {
+		cfg := EncodeOptions{SortMapKeys: true}
+		sorted := fixtures.Sequence{"quad map sorted", fixtures.Tokens{
+			{Type: TMapOpen, Length: 4},
+			TokStr("1"), TokStr("1"),
+			TokStr("b"), TokStr("2"),
+			TokStr("d"), TokStr("4"),
+			TokStr("bc"), TokStr("3"),
+			{Type: TMapClose},
+		}}
+		t.Run("quad map", func(t *testing.T) {
+			expect := encodeFixture(t, sorted)
+			checkEncodingWithOptions(t, cfg, fixtures.SequenceMap["quad map default order"], expect, nil)
+		})
+		t.Run("quad map, indefinite length, stays indefinite", func(t *testing.T) {
+			expect := encodeFixture(t, sorted.SansLengthInfo())
+			checkEncodingWithOptions(t, cfg, fixtures.SequenceMap["quad map default order"].SansLengthInfo(), expect, nil)
+		})
+		t.Run("repeated key", func(t *testing.T) {
+			seq := fixtures.Sequence{"repeated key", fixtures.Tokens{
+				{Type: TMapOpen, Length: 2},
+				TokStr("k"), TokInt(1),
+				TokStr("k"), TokInt(2),
+				{Type: TMapClose},
+			}}
+			outputBuf := &bytes.Buffer{}
+			enc := NewEncoder(outputBuf, cfg)
+			var err error
+			for _, tok := range seq.Tokens {
+				if _, err = enc.Step(&tok); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				t.Errorf("expected error for repeated key")
+			}
+		})
+	})
+	t.Run("canonical options all together", func(t *testing.T) {
+		expect := encodeFixture(t, fixtures.SequenceMap["10 map rfc7049 order"])
+		seq := fixtures.SequenceMap["10 map rfc7049 order"].SansLengthInfo()
+		// Reverse the entries; the encoder should put them back in order.
+		toks := seq.Tokens
+		entries := toks[1 : len(toks)-1]
+		for i, j := 0, len(entries)-2; i < j; i, j = i+2, j-2 {
+			entries[i], entries[i+1], entries[j], entries[j+1] = entries[j], entries[j+1], entries[i], entries[i+1]
+		}
+		checkEncodingWithOptions(t, CanonicalEncodeOptions(), seq, expect, nil)
+	})
+	t.Run("canonical decoding", func(t *testing.T) {
+		cfg := DecodeOptions{Canonical: true}
+		t.Run("canonical input is accepted", func(t *testing.T) {
+			for _, title := range []string{
+				"10 map rfc7049 order",
+				"array nested in map as non-first and final entry",
+				"maps nested in array",
+			} {
+				t.Run(title, func(t *testing.T) {
+					seq := fixtures.SequenceMap[title]
+					checkDecodingWithOptions(t, cfg, seq, encodeFixture(t, seq), nil)
+				})
+			}
+			t.Run("shortest floats", func(t *testing.T) {
+				seq := fixtures.Sequence{"float", fixtures.Tokens{{Type: TFloat64, Float64: 100000}}}
+				checkDecodingWithOptions(t, cfg, seq, bcat(b(0xfa), b(0x47), b(0xc3), b(0x50), b(0x00)), nil)
+			})
+		})
+		for _, tr := range []struct {
+			title  string
+			serial []byte
+			expect error
+		}{
+			{"non-minimal uint", bcat(b(0x18), b(0x01)),
+				ErrNonCanonical{0, "integer or length header is not in shortest form"}},
+			{"non-minimal negint in array", bcat(b(0x82), b(0x01), b(0x39), b(0x00), b(0x01)),
+				ErrNonCanonical{2, "integer or length header is not in shortest form"}},
+			{"non-minimal string length", bcat(b(0x78), b(0x01), []byte("a")),
+				ErrNonCanonical{0, "integer or length header is not in shortest form"}},
+			{"non-minimal tag", bcat(b(0xd8), b(0x01), b(0x01)),
+				ErrNonCanonical{0, "integer or length header is not in shortest form"}},
+			{"indefinite array", bcat(b(0x9f), b(0xff)),
+				ErrNonCanonical{0, "indefinite-length item"}},
+			{"indefinite string in map", bcat(b(0xa1), b(0x61), []byte("k"), b(0x7f), b(0xff)),
+				ErrNonCanonical{3, "indefinite-length item"}},
+			{"double that fits in half", bcat(b(0xfb), b(0x3f), b(0xf8), b(0x00), b(0x00), b(0x00), b(0x00), b(0x00), b(0x00)),
+				ErrNonCanonical{0, "float is not in shortest form"}},
+			{"single that fits in half", bcat(b(0xfa), b(0x3f), b(0xc0), b(0x00), b(0x00)),
+				ErrNonCanonical{0, "float is not in shortest form"}},
+			{"double nan", bcat(b(0xfb), b(0x7f), b(0xf8), b(0x00), b(0x00), b(0x00), b(0x00), b(0x00), b(0x00)),
+				ErrNonCanonical{0, "NaN is not encoded as half-precision 0x7e00"}},
+			{"unsorted keys", bcat(b(0xa2), b(0x62), []byte("bc"), b(0x01), b(0x61), []byte("a"), b(0x02)),
+				ErrNonCanonical{5, "map keys are not in canonical order"}},
+			{"repeated key", bcat(b(0xa2), b(0x61), []byte("a"), b(0x01), b(0x61), []byte("a"), b(0x02)),
+				ErrNonCanonical{4, "repeated map key"}},
+			{"composite key", bcat(b(0xa1), b(0x80), b(0x01)),
+				ErrNonCanonical{1, "map key is not a scalar"}},
+		} {
+			t.Run(tr.title, func(t *testing.T) {
+				// Without canonical mode, all of these are fine.
+				Wish(t, decodeAll(DecodeOptions{}, tr.serial), ShouldEqual, nil)
+				Wish(t, decodeAll(cfg, tr.serial), ShouldEqual, tr.expect)
+			})
+		}
+	})
+}
+
+// Decodes the whole serial, discarding the tokens, and returns any error.
+func decodeAll(cfg DecodeOptions, serial []byte) error {
+	dec := NewDecoder(cfg, bytes.NewBuffer(serial))
+	var tok Token
+	for {
+		done, err := dec.Step(&tok)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// Encodes the fixture with default options.
+func encodeFixture(t *testing.T, seq fixtures.Sequence) []byte {
+	t.Helper()
+	outputBuf := &bytes.Buffer{}
+	enc := NewEncoder(outputBuf, EncodeOptions{})
+	for _, tok := range seq.Tokens {
+		if _, err := enc.Step(&tok); err != nil {
+			t.Fatalf("fixture %q failed to encode: %s", seq.Title, err)
+		}
+	}
+	return outputBuf.Bytes()
+}
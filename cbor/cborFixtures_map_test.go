@@ -3,6 +3,7 @@ package cbor
 import (
 	"testing"
 
+	. "github.com/polydawn/refmt/tok"
 	"github.com/polydawn/refmt/tok/fixtures"
 )
 
@@ -84,4 +85,43 @@ func testMap(t *testing.T) {
 			checkDecoding(t, seq, canon, nil)
 		})
 	})
+
+	t.Run("repeated key", func(t *testing.T) {
+		cfg := DecodeOptions{RejectDuplicateKeys: true}
+		seq := fixtures.Sequence{"repeated key", fixtures.Tokens{
+			{Type: TMapOpen, Length: 3}, TokStr("a"), {Type: TUint, Uint: 1},
+			TokStr("b"), {Type: TMapOpen, Length: 1}, TokStr("a"), {Type: TUint, Uint: 2}, {Type: TMapClose},
+			TokStr("a"), {Type: TUint, Uint: 3},
+			{Type: TMapClose},
+		}}
+		serial := bcat(b(0xa0+3),
+			b(0x60+1), []byte(`a`), b(0x01),
+			b(0x60+1), []byte(`b`), b(0xa0+1), b(0x60+1), []byte(`a`), b(0x02),
+			b(0x60+1), []byte(`a`), b(0x03),
+		)
+		t.Run("decode by default lets it pass", func(t *testing.T) {
+			checkDecoding(t, seq, serial, nil)
+		})
+		t.Run("decode rejecting duplicates errors", func(t *testing.T) {
+			checkDecodingWithOptions(t, cfg, fixtures.Sequence{seq.Title, seq.Tokens[:9]}, serial, ErrDuplicateKey{"a", 10})
+		})
+		t.Run("indefinite length, decode rejecting duplicates errors", func(t *testing.T) {
+			serial := bcat(b(0xbf),
+				b(0x60+1), []byte(`a`), b(0x01),
+				b(0x60+1), []byte(`a`), b(0x03),
+				b(0xff),
+			)
+			seq := fixtures.Sequence{seq.Title, fixtures.Tokens{
+				{Type: TMapOpen, Length: -1}, TokStr("a"), {Type: TUint, Uint: 1}, TokStr("a"),
+			}}
+			checkDecodingWithOptions(t, cfg, seq, serial, ErrDuplicateKey{"a", 4})
+		})
+		t.Run("int keys", func(t *testing.T) {
+			serial := bcat(b(0xa0+2), b(0x01), b(0x01), b(0x01), b(0x02))
+			seq := fixtures.Sequence{seq.Title, fixtures.Tokens{
+				{Type: TMapOpen, Length: 2}, {Type: TUint, Uint: 1}, {Type: TUint, Uint: 1}, {Type: TUint, Uint: 1},
+			}}
+			checkDecodingWithOptions(t, cfg, seq, serial, ErrDuplicateKey{uint64(1), 3})
+		})
+	})
 }
@@ -97,4 +97,19 @@ func testNumber(t *testing.T) {
 			checkDecoding(t, seq, canon, nil)
 		})
 	})
+	t.Run("numbers as text", func(t *testing.T) {
+		// CBOR has no form for these, so they're encoded as whatever fits their value.
+		t.Run("integer", func(t *testing.T) {
+			seq := fixtures.Sequence{"number text 10", fixtures.Tokens{{Type: TNumber, Str: "10"}}}
+			checkEncoding(t, seq, b(0x0a), nil)
+		})
+		t.Run("float", func(t *testing.T) {
+			seq := fixtures.Sequence{"number text 1.5", fixtures.Tokens{{Type: TNumber, Str: "1.5"}}}
+			checkEncodingWithOptions(t, EncodeOptions{ShortestFloats: true}, seq, bcat(b(0xf9), b(0x3e), b(0x00)), nil)
+		})
+		t.Run("invalid", func(t *testing.T) {
+			seq := fixtures.Sequence{"number text invalid", fixtures.Tokens{{Type: TNumber, Str: "1.5.5"}}}
+			checkEncoding(t, seq, nil, ErrInvalidNumber{"1.5.5"})
+		})
+	})
 }
@@ -0,0 +1,82 @@
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func testStream(t *testing.T) {
+	t.Run("decode sequence", func(t *testing.T) {
+		checkDecodingStream(t, bcat(
+			b(0x80+1), b(0x60+5), []byte(`value`),
+			b(0xf5),
+			b(0x07),
+		), []fixtures.Sequence{
+			fixtures.SequenceMap["single entry array"],
+			fixtures.SequenceMap["true"],
+			{Tokens: fixtures.Tokens{{Type: TUint, Uint: 7}}},
+		}, nil)
+	})
+	t.Run("decode empty sequence", func(t *testing.T) {
+		checkDecodingStream(t, []byte{}, nil, nil)
+	})
+	t.Run("decode sequence with an incomplete value", func(t *testing.T) {
+		checkDecodingStream(t, bcat(b(0xf5), b(0x80+1)), []fixtures.Sequence{
+			fixtures.SequenceMap["true"],
+			fixtures.SequenceMap["dangling arr open"],
+		}, io.EOF)
+	})
+	t.Run("marshal and unmarshal each value", func(t *testing.T) {
+		var buf bytes.Buffer
+		m := NewMarshaller(&buf)
+		Wish(t, m.Marshal(map[string]int{"a": 1}), ShouldEqual, nil)
+		Wish(t, m.Marshal(map[string]int{"a": 2}), ShouldEqual, nil)
+		Wish(t, buf.Bytes(), ShouldEqual, bcat(
+			b(0xa0+1), b(0x60+1), []byte(`a`), b(0x01),
+			b(0xa0+1), b(0x60+1), []byte(`a`), b(0x02),
+		))
+
+		u := NewUnmarshaller(DecodeOptions{}, &buf)
+		var vs []map[string]int
+		for u.More() {
+			var v map[string]int
+			Wish(t, u.Unmarshal(&v), ShouldEqual, nil)
+			vs = append(vs, v)
+		}
+		Wish(t, vs, ShouldEqual, []map[string]int{{"a": 1}, {"a": 2}})
+	})
+}
+
+func checkDecodingStream(t *testing.T, serial []byte, expectSequences []fixtures.Sequence, expectErr error) {
+	t.Helper()
+	tokenSrc := NewDecoder(DecodeOptions{}, bytes.NewBuffer(serial))
+
+	// Decode values for as long as the decoder says there are more,
+	//  stopping at the first error.
+	//  As in checkDecoding, the token slot of a step which errored is recorded too.
+	var yields []fixtures.Tokens
+	var err error
+	for err == nil && tokenSrc.More() {
+		tokenSrc.Reset()
+		var yield fixtures.Tokens
+		for done := false; !done && err == nil; {
+			var tok Token
+			done, err = tokenSrc.Step(&tok)
+			yield = append(yield, tok)
+		}
+		yields = append(yields, yield)
+	}
+
+	var expectYields []fixtures.Tokens
+	for _, seq := range expectSequences {
+		expectYields = append(expectYields, seq.Tokens)
+	}
+	Wish(t, yields, ShouldEqual, expectYields)
+	Wish(t, err, ShouldEqual, expectErr)
+}
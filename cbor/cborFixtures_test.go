@@ -19,12 +19,20 @@ func Test(t *testing.T) {
 	testNumber(t)
 	testBytes(t)
 	testTags(t)
+	testBignum(t)
+	testCanonical(t)
+	testStream(t)
 }
 
 func checkEncoding(t *testing.T, sequence fixtures.Sequence, expectSerial []byte, expectErr error) {
+	t.Helper()
+	checkEncodingWithOptions(t, EncodeOptions{}, sequence, expectSerial, expectErr)
+}
+
+func checkEncodingWithOptions(t *testing.T, cfg EncodeOptions, sequence fixtures.Sequence, expectSerial []byte, expectErr error) {
 	t.Helper()
 	outputBuf := &bytes.Buffer{}
-	tokenSink := NewEncoder(outputBuf)
+	tokenSink := NewEncoder(outputBuf, cfg)
 
 	// Run steps, advancing through the token sequence.
 	//  If it stops early, just report how many steps in; we Wish on that value.
@@ -48,9 +56,14 @@ func checkEncoding(t *testing.T, sequence fixtures.Sequence, expectSerial []byte
 }
 
 func checkDecoding(t *testing.T, expectSequence fixtures.Sequence, serial []byte, expectErr error) {
+	t.Helper()
+	checkDecodingWithOptions(t, DecodeOptions{}, expectSequence, serial, expectErr)
+}
+
+func checkDecodingWithOptions(t *testing.T, cfg DecodeOptions, expectSequence fixtures.Sequence, serial []byte, expectErr error) {
 	t.Helper()
 	inputBuf := bytes.NewBuffer(serial)
-	tokenSrc := NewDecoder(DecodeOptions{}, inputBuf)
+	tokenSrc := NewDecoder(cfg, inputBuf)
 
 	// Run steps, advancing until the decoder reports it's done.
 	//  If the decoder keeps yielding more tokens than we expect, that's fine...
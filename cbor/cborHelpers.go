@@ -34,9 +34,9 @@ func Marshal(v interface{}) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func MarshalAtlased(v interface{}, atl atlas.Atlas) ([]byte, error) {
+func MarshalAtlased(cfg EncodeOptions, v interface{}, atl atlas.Atlas) ([]byte, error) {
 	var buf bytes.Buffer
-	if err := NewMarshallerAtlased(&buf, atl).Marshal(v); err != nil {
+	if err := NewMarshallerAtlased(&buf, cfg, atl).Marshal(v); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
@@ -55,13 +55,13 @@ func (x *Marshaller) Marshal(v interface{}) error {
 }
 
 func NewMarshaller(wr io.Writer) *Marshaller {
-	return NewMarshallerAtlased(wr, atlas.MustBuild())
+	return NewMarshallerAtlased(wr, EncodeOptions{}, atlas.MustBuild())
 }
 
-func NewMarshallerAtlased(wr io.Writer, atl atlas.Atlas) *Marshaller {
+func NewMarshallerAtlased(wr io.Writer, cfg EncodeOptions, atl atlas.Atlas) *Marshaller {
 	x := &Marshaller{
 		marshaller: obj.NewMarshaller(atl),
-		encoder:    NewEncoder(wr),
+		encoder:    NewEncoder(wr, cfg),
 	}
 	x.pump = shared.TokenPump{
 		x.marshaller,
@@ -90,12 +90,18 @@ func (x *Unmarshaller) Unmarshal(v interface{}) error {
 	return x.pump.Run()
 }
 
+// More reports whether there's another value in the input to unmarshal.
+// Loop on it to unmarshal each value of a CBOR Sequence in turn.
+func (x *Unmarshaller) More() bool {
+	return x.decoder.More()
+}
+
 func NewUnmarshaller(cfg DecodeOptions, r io.Reader) *Unmarshaller {
 	return NewUnmarshallerAtlased(cfg, r, atlas.MustBuild())
 }
 func NewUnmarshallerAtlased(cfg DecodeOptions, r io.Reader, atl atlas.Atlas) *Unmarshaller {
 	x := &Unmarshaller{
-		unmarshaller: obj.NewUnmarshaller(atl),
+		unmarshaller: obj.NewUnmarshaller(atl, obj.UnmarshalOptions{IgnoreUnknownFields: cfg.IgnoreUnknownFields}),
 		decoder:      NewDecoder(cfg, r),
 	}
 	x.pump = shared.TokenPump{
@@ -1,8 +1,34 @@
 package cbor
 
 type EncodeOptions struct {
-	// there aren't a ton of options for cbor, but we still need this
-	// for use as a sigil for the top-level refmt methods to demux on.
+	// If set, floats are emitted in the shortest of the half-, single-,
+	// or double-precision forms that represents the value exactly.
+	// (By default, all floats are emitted in double-precision.)
+	ShortestFloats bool
+
+	// If set, maps and arrays are always emitted with definite lengths,
+	// even if the token stream doesn't know the length in advance.
+	// (This requires buffering those maps and arrays until they're complete.)
+	DefiniteLengths bool
+
+	// If set, map entries are emitted in RFC7049 canonical order
+	// (shorter keys first, then bytewise), regardless of the order in the
+	// token stream -- meaning, regardless of how the atlas is configured.
+	// A map with repeated keys is an error.
+	// (This requires buffering every map until it's complete.)
+	SortMapKeys bool
+}
+
+// CanonicalEncodeOptions returns options for deterministic encoding,
+// per RFC 8949 section 4.2 (using the length-first key ordering described
+// in section 4.2.3, which is the canonical order from RFC7049).
+// Use this if you're going to hash the output.
+func CanonicalEncodeOptions() EncodeOptions {
+	return EncodeOptions{
+		ShortestFloats:  true,
+		DefiniteLengths: true,
+		SortMapKeys:     true,
+	}
 }
 
 // marker method -- you may use this type to instruct `refmt.Marshal`
@@ -12,7 +38,22 @@ func (EncodeOptions) IsEncodeOptions() {}
 type DecodeOptions struct {
 	CoerceUndefToNull bool
 
-	// future: options to validate canonical serial order
+	// If set, the decoder rejects any input which is not in canonical form
+	// (the same form produced by `CanonicalEncodeOptions`):
+	// integers and lengths must use the shortest header; indefinite-length
+	// items are not allowed; floats must use the shortest precision which
+	// represents the value exactly; and map keys must be scalars, in
+	// RFC7049 canonical order, with no repeats.
+	// Violations are reported as ErrNonCanonical.
+	Canonical bool
+
+	// If set, a map which repeats a scalar key (compared by value, so a
+	// bignum can repeat a plain integer) is rejected with an ErrDuplicateKey.
+	RejectDuplicateKeys bool
+
+	// Passed on to the object unmarshaller by the Unmarshaller helpers;
+	// see `obj.UnmarshalOptions.IgnoreUnknownFields`.  The Decoder ignores it.
+	IgnoreUnknownFields bool
 }
 
 // marker method -- you may use this type to instruct `refmt.Marshal`
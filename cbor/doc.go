@@ -16,6 +16,11 @@
 	the marshaller instances will significantly cut down on memory allocations
 	and improve performance.
 
+	To read or write a CBOR Sequence (RFC 8742) -- several values one after
+	another -- reuse one Unmarshaller, calling `Unmarshal` for as long as
+	`More` reports there's input left; and reuse one Marshaller, calling
+	`Marshal` once per value.  (No separators are needed.)
+
 	The `*Atlased` variants of constructors allow you set up marshalling with
 	an `refmt/obj/atlas.Atlas`, unlocking all of refmt's advanced features
 	and custom object mapping powertools.
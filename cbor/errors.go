@@ -18,5 +18,42 @@ func (e *ErrInvalidTokenStream) Error() string {
 	// More comprehensible strings might include "start of value", "start of key or end of map", "start of value or end of array".
 }
 
-var tokenTypesForKey = []TokenType{TString, TInt, TUint}
-var tokenTypesForValue = []TokenType{TMapOpen, TArrOpen, TNull, TString, TBytes, TInt, TUint, TFloat64}
+// Error raised by Decoder when the input is not valid cbor
+// (or uses cbor features we don't support).
+type ErrSyntax struct {
+	Offset int   // Byte offset of the start of the item which couldn't be decoded.
+	Err    error // What went wrong.
+}
+
+func (e ErrSyntax) Error() string {
+	return fmt.Sprintf("cbor: syntax error in item at byte offset %d: %s", e.Offset, e.Err)
+}
+
+func (e ErrSyntax) Unwrap() error {
+	return e.Err
+}
+
+// Error raised by Decoder when configured to require canonical form,
+// and the input is valid cbor, but not canonical.
+type ErrNonCanonical struct {
+	Offset int    // Byte offset of the start of the offending item.
+	Reason string // Which rule of canonical form was violated.
+}
+
+func (e ErrNonCanonical) Error() string {
+	return fmt.Sprintf("cbor: input is not in canonical form at byte offset %d: %s", e.Offset, e.Reason)
+}
+
+// Error raised by Decoder when configured to reject duplicate keys,
+// and a map contains the same key more than once.
+type ErrDuplicateKey struct {
+	Key    interface{} // The repeated key: a string, int64, uint64, []byte, etc, per the key's token type.
+	Offset int         // Byte offset of the start of the repeated key.
+}
+
+func (e ErrDuplicateKey) Error() string {
+	return fmt.Sprintf("cbor: repeated map key %#v at byte offset %d", e.Key, e.Offset)
+}
+
+var tokenTypesForKey = []TokenType{TString, TInt, TUint, TBigInt}
+var tokenTypesForValue = []TokenType{TMapOpen, TArrOpen, TNull, TString, TBytes, TInt, TUint, TFloat64, TBigInt, TBigFloat}
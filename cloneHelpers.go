@@ -31,7 +31,7 @@ type Cloner interface {
 func NewCloner(atl atlas.Atlas) Cloner {
 	x := &cloner{
 		marshaller:   obj.NewMarshaller(atl),
-		unmarshaller: obj.NewUnmarshaller(atl),
+		unmarshaller: obj.NewUnmarshaller(atl, obj.UnmarshalOptions{}),
 	}
 	x.pump = shared.TokenPump{x.marshaller, x.unmarshaller}
 	return x
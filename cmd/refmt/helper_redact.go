@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/polydawn/refmt/shared"
+	"github.com/polydawn/refmt/tok"
+)
+
+var redactFlag = cli.StringSliceFlag{
+	Name:  "redact",
+	Usage: "replace the value of every map entry with this key by \"[redacted]\" (may be repeated)",
+}
+
+/*
+	Wrap the token source so the values of any keys named by the redact flag
+	are replaced.  Redaction happens on the token stream, so it works the same
+	for any input format, and nothing is materialized.
+*/
+func redacted(c *cli.Context, src shared.TokenSource) shared.TokenSource {
+	keys := c.StringSlice(redactFlag.Name)
+	if len(keys) == 0 {
+		return src
+	}
+	redact := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		redact[k] = struct{}{}
+	}
+	return shared.NewTransformingTokenSource(src, shared.ReplaceValues(func(key string) (tok.Token, bool) {
+		_, ok := redact[key]
+		return tok.Token{Type: tok.TString, Str: "[redacted]"}, ok
+	}))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli"
+
+	"github.com/polydawn/refmt/cbor"
+	"github.com/polydawn/refmt/json"
+	"github.com/polydawn/refmt/pretty"
+	"github.com/polydawn/refmt/selector"
+	"github.com/polydawn/refmt/shared"
+)
+
+/*
+	Run the "select" command: decode stdin in the format named by the
+	"from" flag, and emit every value matching the path in the format
+	named by the "to" flag.  Text formats get a line break after each match.
+*/
+func selectAction(c *cli.Context, stdin io.Reader, stdout io.Writer) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("select requires exactly one argument: the path to select")
+	}
+	path, err := selector.Parse(c.Args().First())
+	if err != nil {
+		return err
+	}
+	var src shared.TokenSource
+	switch from := c.String("from"); from {
+	case "json":
+		src = json.NewDecoder(json.DecodeOptions{}, stdin)
+	case "json5":
+		src = json.NewDecoder(json.DecodeOptions{JSON5: true}, stdin)
+	case "cbor":
+		src = cbor.NewDecoder(cbor.DecodeOptions{}, stdin)
+	case "cbor.hex":
+		src = cbor.NewDecoder(cbor.DecodeOptions{}, hexReader(stdin))
+	case "yaml":
+		src = newYamlTokenSource(stdin)
+	default:
+		return fmt.Errorf("unknown input format %q", from)
+	}
+	var sink resettableSink
+	var separator []byte
+	switch to := c.String("to"); to {
+	case "json":
+		sink = json.NewEncoder(stdout, json.EncodeOptions{})
+		separator = []byte{'\n'}
+	case "cbor":
+		sink = cbor.NewEncoder(stdout, cbor.EncodeOptions{})
+	case "cbor.hex":
+		sink = cbor.NewEncoder(hexWriter{stdout}, cbor.EncodeOptions{})
+		separator = []byte{'\n'}
+	case "pretty":
+		sink = pretty.NewEncoder(stdout) // already ends each value with a line break.
+	default:
+		return fmt.Errorf("unknown output format %q", to)
+	}
+	sel := selector.NewSelector(path, redacted(c, src))
+	for {
+		sink.Reset()
+		if err := (shared.TokenPump{sel, sink}).Run(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if _, err := stdout.Write(separator); err != nil {
+			return err
+		}
+	}
+}
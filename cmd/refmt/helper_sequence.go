@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/polydawn/refmt/shared"
+)
+
+// A token source for a format which can hold several top-level values
+// one after another: newline-delimited JSON, or a CBOR Sequence.
+type sequenceDecoder interface {
+	shared.TokenSource
+	More() bool
+	Reset()
+}
+
+type resettableSink interface {
+	shared.TokenSink
+	Reset()
+}
+
+/*
+	Pump each value in the decoder's input to the sink in turn, until the
+	input runs out.  The sink is reset between values, so it's up to the
+	sink's configuration whether anything is written to separate them.
+*/
+func pumpSequence(c *cli.Context, dec sequenceDecoder, sink resettableSink) error {
+	src := redacted(c, dec)
+	for dec.More() {
+		dec.Reset()
+		sink.Reset()
+		if err := (shared.TokenPump{src, sink}).Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -33,7 +33,7 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Usage:    "read json, then pretty print it",
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					json.NewDecoder(stdin),
+					json.NewDecoder(json.DecodeOptions{}, stdin),
 					pretty.NewEncoder(stdout),
 				}.Run()
 			},
@@ -60,6 +60,28 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 				}.Run()
 			},
 		},
+		cli.Command{
+			Category: "prettyprint",
+			Name:     "json5=pretty",
+			Usage:    "read json5 (json with comments, trailing commas, and so on), then pretty print it",
+			Action: func(c *cli.Context) error {
+				return shared.TokenPump{
+					json.NewDecoder(json.DecodeOptions{JSON5: true}, stdin),
+					pretty.NewEncoder(stdout),
+				}.Run()
+			},
+		},
+		cli.Command{
+			Category: "prettyprint",
+			Name:     "ndjson=pretty",
+			Usage:    "read newline-delimited json, then pretty print each value",
+			Action: func(c *cli.Context) error {
+				return pumpSequence(c,
+					json.NewDecoder(json.DecodeOptions{}, stdin),
+					pretty.NewEncoder(stdout),
+				)
+			},
+		},
 		cli.Command{
 			Category: "prettyprint",
 			Name:     "yaml=pretty",
@@ -78,10 +100,11 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Category: "convert",
 			Name:     "json=cbor",
 			Usage:    "read json, emit equivalent cbor",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					json.NewDecoder(stdin),
-					cbor.NewEncoder(stdout),
+					redacted(c, json.NewDecoder(json.DecodeOptions{}, stdin)),
+					cbor.NewEncoder(stdout, cbor.EncodeOptions{}),
 				}.Run()
 			},
 		},
@@ -89,10 +112,11 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Category: "convert",
 			Name:     "json=cbor.hex",
 			Usage:    "read json, emit equivalent cbor in hex",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					json.NewDecoder(stdin),
-					cbor.NewEncoder(hexWriter{stdout}),
+					redacted(c, json.NewDecoder(json.DecodeOptions{}, stdin)),
+					cbor.NewEncoder(hexWriter{stdout}, cbor.EncodeOptions{}),
 				}.Run()
 			},
 		},
@@ -100,9 +124,10 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Category: "convert",
 			Name:     "cbor=json",
 			Usage:    "read cbor, emit equivalent json",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					cbor.NewDecoder(cbor.DecodeOptions{}, stdin),
+					redacted(c, cbor.NewDecoder(cbor.DecodeOptions{}, stdin)),
 					json.NewEncoder(stdout, json.EncodeOptions{}),
 				}.Run()
 			},
@@ -111,20 +136,46 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Category: "convert",
 			Name:     "cbor.hex=json",
 			Usage:    "read cbor in hex, emit equivalent json",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					cbor.NewDecoder(cbor.DecodeOptions{}, hexReader(stdin)),
+					redacted(c, cbor.NewDecoder(cbor.DecodeOptions{}, hexReader(stdin))),
 					json.NewEncoder(stdout, json.EncodeOptions{}),
 				}.Run()
 			},
 		},
+		cli.Command{
+			Category: "convert",
+			Name:     "json5=json",
+			Usage:    "read json5 (json with comments, trailing commas, and so on), emit equivalent plain json",
+			Flags:    []cli.Flag{redactFlag},
+			Action: func(c *cli.Context) error {
+				return shared.TokenPump{
+					redacted(c, json.NewDecoder(json.DecodeOptions{JSON5: true}, stdin)),
+					json.NewEncoder(stdout, json.EncodeOptions{}),
+				}.Run()
+			},
+		},
+		cli.Command{
+			Category: "convert",
+			Name:     "json5=cbor",
+			Usage:    "read json5 (json with comments, trailing commas, and so on), emit equivalent cbor",
+			Flags:    []cli.Flag{redactFlag},
+			Action: func(c *cli.Context) error {
+				return shared.TokenPump{
+					redacted(c, json.NewDecoder(json.DecodeOptions{JSON5: true}, stdin)),
+					cbor.NewEncoder(stdout, cbor.EncodeOptions{}),
+				}.Run()
+			},
+		},
 		cli.Command{
 			Category: "convert",
 			Name:     "yaml=json",
 			Usage:    "read yaml, emit equivalent json",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					newYamlTokenSource(stdin),
+					redacted(c, newYamlTokenSource(stdin)),
 					json.NewEncoder(stdout, json.EncodeOptions{}),
 				}.Run()
 			},
@@ -133,10 +184,11 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Category: "convert",
 			Name:     "yaml=cbor",
 			Usage:    "read yaml, emit equivalent cbor",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					newYamlTokenSource(stdin),
-					cbor.NewEncoder(stdout),
+					redacted(c, newYamlTokenSource(stdin)),
+					cbor.NewEncoder(stdout, cbor.EncodeOptions{}),
 				}.Run()
 			},
 		},
@@ -144,13 +196,55 @@ func Main(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			Category: "convert",
 			Name:     "yaml=cbor.hex",
 			Usage:    "read yaml, emit equivalent cbor in hex",
+			Flags:    []cli.Flag{redactFlag},
 			Action: func(c *cli.Context) error {
 				return shared.TokenPump{
-					newYamlTokenSource(stdin),
-					cbor.NewEncoder(hexWriter{stdout}),
+					redacted(c, newYamlTokenSource(stdin)),
+					cbor.NewEncoder(hexWriter{stdout}, cbor.EncodeOptions{}),
 				}.Run()
 			},
 		},
+		cli.Command{
+			Category: "convert",
+			Name:     "ndjson=cbor",
+			Usage:    "read newline-delimited json, emit a cbor sequence of the equivalent values",
+			Flags:    []cli.Flag{redactFlag},
+			Action: func(c *cli.Context) error {
+				return pumpSequence(c,
+					json.NewDecoder(json.DecodeOptions{}, stdin),
+					cbor.NewEncoder(stdout, cbor.EncodeOptions{}),
+				)
+			},
+		},
+		cli.Command{
+			Category: "convert",
+			Name:     "cbor=ndjson",
+			Usage:    "read a cbor sequence, emit the equivalent values as newline-delimited json",
+			Flags:    []cli.Flag{redactFlag},
+			Action: func(c *cli.Context) error {
+				return pumpSequence(c,
+					cbor.NewDecoder(cbor.DecodeOptions{}, stdin),
+					json.NewEncoder(stdout, json.EncodeOptions{Terminator: []byte{'\n'}}),
+				)
+			},
+		},
+		//
+		// Extractors
+		//
+		cli.Command{
+			Category:  "extract",
+			Name:      "select",
+			Usage:     "read a document, and emit only the values at a path (like `.a.b[2].c`; `.*` and `[*]` are wildcards)",
+			ArgsUsage: "<path>",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "from", Value: "json", Usage: "input format: json, json5, cbor, cbor.hex, or yaml"},
+				cli.StringFlag{Name: "to", Value: "json", Usage: "output format: json, cbor, cbor.hex, or pretty"},
+				redactFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return selectAction(c, stdin, stdout)
+			},
+		},
 	}
 	app.Writer = stdout
 	app.ErrWriter = stderr
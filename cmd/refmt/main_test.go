@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+)
+
+func run(stdin string, args ...string) (stdout, stderr string, code int) {
+	var out, errOut bytes.Buffer
+	code = Main(append([]string{"refmt"}, args...), strings.NewReader(stdin), &out, &errOut)
+	return out.String(), errOut.String(), code
+}
+
+func TestSelect(t *testing.T) {
+	t.Run("several map matches", func(t *testing.T) {
+		stdout, stderr, code := run(`{"a":[{"x":1},{"x":2},{"x":3}]}`, "select", ".a[*]")
+		Wish(t, stderr, ShouldEqual, "")
+		Wish(t, code, ShouldEqual, 0)
+		Wish(t, stdout, ShouldEqual, "{\"x\":1}\n{\"x\":2}\n{\"x\":3}\n")
+	})
+	t.Run("several array matches", func(t *testing.T) {
+		stdout, stderr, code := run(`{"a":{"p":[1,2],"q":[3]}}`, "select", ".a.*")
+		Wish(t, stderr, ShouldEqual, "")
+		Wish(t, code, ShouldEqual, 0)
+		Wish(t, stdout, ShouldEqual, "[1,2]\n[3]\n")
+	})
+	t.Run("mixed matches to cbor", func(t *testing.T) {
+		stdout, stderr, code := run(`[{"x":1},[2],{"y":3}]`, "select", "--to", "cbor.hex", "[*]")
+		Wish(t, stderr, ShouldEqual, "")
+		Wish(t, code, ShouldEqual, 0)
+		Wish(t, stdout, ShouldEqual, "bf617801ff\n9f02ff\nbf617903ff\n")
+	})
+}
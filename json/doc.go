@@ -9,6 +9,11 @@
 	the marshaller instances will significantly cut down on memory allocations
 	and improve performance.
 
+	To read or write a stream of several values -- such as newline-delimited
+	JSON -- reuse one Unmarshaller, calling `Unmarshal` for as long as `More`
+	reports there's input left; and reuse one Marshaller, with
+	`EncodeOptions.Terminator` set to a newline.
+
 	The `*Atlased` variants of constructors allow you set up marshalling with
 	an `refmt/obj/atlas.Atlas`, unlocking all of refmt's advanced features
 	and custom object mapping powertools.
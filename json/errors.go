@@ -0,0 +1,34 @@
+package json
+
+import (
+	"fmt"
+)
+
+// Error raised by Decoder when the input is not valid json.
+// Offset, Line, and Column all describe the position of the byte where the
+// problem was noticed; Line and Column count from one.
+type ErrSyntax struct {
+	Offset int
+	Line   int
+	Column int
+	Err    error // What went wrong.
+}
+
+func (e ErrSyntax) Error() string {
+	return fmt.Sprintf("json: syntax error at line %d, column %d (byte offset %d): %s", e.Line, e.Column, e.Offset, e.Err)
+}
+
+func (e ErrSyntax) Unwrap() error {
+	return e.Err
+}
+
+// Error raised by Decoder when configured to reject duplicate keys,
+// and a map contains the same key more than once.
+type ErrDuplicateKey struct {
+	Key    string
+	Offset int // Byte offset of the start of the repeated key.
+}
+
+func (e ErrDuplicateKey) Error() string {
+	return fmt.Sprintf("json: repeated map key %q at byte offset %d", e.Key, e.Offset)
+}
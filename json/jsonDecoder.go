@@ -14,14 +14,22 @@ type stackFrame struct {
 }
 
 type Decoder struct {
-	r shared.SlickReader
+	cfg DecodeOptions
+	r   shared.SlickReader
 
-	stack []stackFrame // When empty, and step returns done, all done.
-	frame stackFrame   // Shortcut to end of stack.
+	stack []stackFrame          // When empty, and step returns done, all done.
+	frame stackFrame            // Shortcut to end of stack.
+	keys  []map[string]struct{} // Keys seen in each map we're within.  Only used if rejecting duplicate keys.
+
+	line       int   // Number of newlines seen so far.  For error reporting.
+	lineStart  int   // Offset of the first byte of the current line.  For error reporting.
+	tokenStart int   // Offset of the first byte of the most recently yielded token.
+	pendingErr error // Error hit while peeking in More; returned by the next Step.
 }
 
-func NewDecoder(r io.Reader) (d *Decoder) {
+func NewDecoder(cfg DecodeOptions, r io.Reader) (d *Decoder) {
 	d = &Decoder{
+		cfg:   cfg,
 		r:     shared.NewReader(r),
 		stack: make([]stackFrame, 0, 10),
 	}
@@ -32,15 +40,20 @@ func NewDecoder(r io.Reader) (d *Decoder) {
 func (d *Decoder) Reset() {
 	d.stack = d.stack[0:0]
 	d.frame = stackFrame{d.step_acceptValue, false}
+	d.keys = d.keys[0:0]
 }
 
 type decoderStep func(tokenSlot *Token) (done bool, err error)
 
 func (d *Decoder) Step(tokenSlot *Token) (done bool, err error) {
+	if d.pendingErr != nil {
+		err, d.pendingErr = d.pendingErr, nil
+		return true, d.syntaxError(err)
+	}
 	done, err = d.frame.step(tokenSlot)
 	// If the step errored: out, entirely.
 	if err != nil {
-		return true, err
+		return true, d.syntaxError(err)
 	}
 	// If the step wasn't done, return same status.
 	if !done {
@@ -49,6 +62,11 @@ func (d *Decoder) Step(tokenSlot *Token) (done bool, err error) {
 	// If it WAS done, and stack empty, we're entirely done.
 	nSteps := len(d.stack) - 1
 	if nSteps <= 0 {
+		if d.cfg.Strict {
+			if err := d.checkEnd(); err != nil {
+				return true, d.syntaxError(err)
+			}
+		}
 		return true, nil // that's all folks
 	}
 	// Pop the stack.  Reset "some" to true.
@@ -57,26 +75,141 @@ func (d *Decoder) Step(tokenSlot *Token) (done bool, err error) {
 	return false, nil
 }
 
+/*
+	More reports whether there's another value in the input: that is,
+	whether anything but whitespace remains before the end.
+
+	Use it to decode a stream of several top-level values, such as
+	newline-delimited JSON: loop while More is true, calling Reset before
+	stepping through each value.  (The Unmarshaller does the resetting.)
+
+	If reading fails, More reports true, and the error is returned by the
+	next Step.
+*/
+func (d *Decoder) More() bool {
+	_, err := d.readn1skippingWhitespace()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		d.pendingErr = err
+		return true
+	}
+	d.r.Unreadn1()
+	return true
+}
+
 func (d *Decoder) pushPhase(newPhase decoderStep) {
 	d.stack = append(d.stack, d.frame)
 	d.frame = stackFrame{newPhase, false}
 }
 
-func readn1skippingWhitespace(r shared.SlickReader) (majorByte byte, err error) {
+func (d *Decoder) readn1skippingWhitespace() (majorByte byte, err error) {
 	for {
-		majorByte, err = r.Readn1()
+		majorByte, err = d.r.Readn1()
 		switch majorByte {
-		case ' ', '\t', '\r', '\n': // continue
+		case '\n':
+			d.line++
+			d.lineStart = d.r.NumRead()
+		case ' ', '\t', '\r': // continue
+		case '/':
+			if !d.cfg.JSON5 {
+				return
+			}
+			if err = d.skipComment(); err != nil {
+				return
+			}
 		default:
 			return
 		}
 	}
 }
 
+// Skips a comment, the leading slash having already been eaten.
+func (d *Decoder) skipComment() error {
+	b, err := d.r.Readn1()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	switch b {
+	case '/':
+		for {
+			b, err = d.r.Readn1()
+			if err == io.EOF {
+				return nil // A line comment may end the input.
+			}
+			if err != nil {
+				return err
+			}
+			if b == '\n' {
+				d.line++
+				d.lineStart = d.r.NumRead()
+				return nil
+			}
+		}
+	case '*':
+		for prev := byte(0); ; prev = b {
+			b, err = d.r.Readn1()
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return err
+			}
+			if b == '\n' {
+				d.line++
+				d.lineStart = d.r.NumRead()
+			}
+			if prev == '*' && b == '/' {
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("invalid char after slash; expected comment: %s", byteToString(b))
+	}
+}
+
+// Checks that nothing but whitespace follows the top-level value.
+func (d *Decoder) checkEnd() error {
+	majorByte, err := d.readn1skippingWhitespace()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("unexpected data after top-level value: %s", byteToString(majorByte))
+}
+
+// Returns the byte offset in the input where the most recently yielded token began.
+func (d *Decoder) TokenOffset() int {
+	return d.tokenStart
+}
+
+// Wraps an error in ErrSyntax, saying where in the input we were when it happened.
+// EOF is left as is, as are errors which already carry their own position.
+func (d *Decoder) syntaxError(err error) error {
+	switch err.(type) {
+	case ErrDuplicateKey:
+		return err
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return err
+	}
+	offset := d.r.NumRead() - 1
+	if offset < 0 {
+		offset = 0
+	}
+	return ErrSyntax{offset, d.line + 1, offset - d.lineStart + 1, err}
+}
+
 // The original step, where any value is accepted, and no terminators for composites are valid.
 // ONLY used in the original step; all other steps handle leaf nodes internally.
 func (d *Decoder) step_acceptValue(tokenSlot *Token) (done bool, err error) {
-	majorByte, err := readn1skippingWhitespace(d.r)
+	majorByte, err := d.readn1skippingWhitespace()
 	if err != nil {
 		return true, err
 	}
@@ -85,20 +218,24 @@ func (d *Decoder) step_acceptValue(tokenSlot *Token) (done bool, err error) {
 
 // Step in midst of decoding an array.
 func (d *Decoder) step_acceptArrValueOrBreak(tokenSlot *Token) (done bool, err error) {
-	majorByte, err := readn1skippingWhitespace(d.r)
+	majorByte, err := d.readn1skippingWhitespace()
 	if err != nil {
 		return true, err
 	}
 	if d.frame.some {
 		switch majorByte {
 		case ']':
+			d.tokenStart = d.r.NumRead() - 1
 			tokenSlot.Type = TArrClose
 			return true, nil
 		case ',':
-			majorByte, err = readn1skippingWhitespace(d.r)
+			majorByte, err = d.readn1skippingWhitespace()
 			if err != nil {
 				return true, err
 			}
+			if majorByte == ']' && d.cfg.Strict && !d.cfg.JSON5 {
+				return true, fmt.Errorf("trailing comma before array close")
+			}
 			// and now fall through to the next switch
 		default:
 			return true, fmt.Errorf("expected comma or array close after array value; got %s", byteToString(majorByte))
@@ -106,6 +243,7 @@ func (d *Decoder) step_acceptArrValueOrBreak(tokenSlot *Token) (done bool, err e
 	}
 	switch majorByte {
 	case ']':
+		d.tokenStart = d.r.NumRead() - 1
 		tokenSlot.Type = TArrClose
 		return true, nil
 	default:
@@ -117,20 +255,25 @@ func (d *Decoder) step_acceptArrValueOrBreak(tokenSlot *Token) (done bool, err e
 
 // Step in midst of decoding a map, key expected up next, or end.
 func (d *Decoder) step_acceptMapKeyOrBreak(tokenSlot *Token) (done bool, err error) {
-	majorByte, err := readn1skippingWhitespace(d.r)
+	majorByte, err := d.readn1skippingWhitespace()
 	if err != nil {
 		return true, err
 	}
 	if d.frame.some {
 		switch majorByte {
 		case '}':
+			d.tokenStart = d.r.NumRead() - 1
+			d.popKeys()
 			tokenSlot.Type = TMapClose
 			return true, nil
 		case ',':
-			majorByte, err = readn1skippingWhitespace(d.r)
+			majorByte, err = d.readn1skippingWhitespace()
 			if err != nil {
 				return true, err
 			}
+			if majorByte == '}' && d.cfg.Strict && !d.cfg.JSON5 {
+				return true, fmt.Errorf("trailing comma before map close")
+			}
 			// and now fall through to the next switch
 		default:
 			return true, fmt.Errorf("expected comma or map close after map value; got %s", byteToString(majorByte))
@@ -138,17 +281,38 @@ func (d *Decoder) step_acceptMapKeyOrBreak(tokenSlot *Token) (done bool, err err
 	}
 	switch majorByte {
 	case '}':
+		d.tokenStart = d.r.NumRead() - 1
+		d.popKeys()
 		tokenSlot.Type = TMapClose
 		return true, nil
 	default:
 		d.frame.some = true
 		// Consume a string for key.
-		_, err := d.stepHelper_acceptKey(majorByte, tokenSlot) // FIXME surely not *any* value?  not composites, at least?
+		//  Unless strict, any scalar is tolerated here; it's up to the unmarshaller what to make of it.
+		//  Composites never are.  JSON5 allows strings in either quote, and bare identifiers.
+		offset := d.r.NumRead() - 1
+		switch {
+		case d.cfg.JSON5 && isIdentStart(majorByte):
+			d.tokenStart = offset
+			tokenSlot.Type = TString
+			tokenSlot.Str, err = d.decodeIdentifier()
+		case majorByte == '{' || majorByte == '[',
+			majorByte != '"' && d.cfg.Strict && !d.cfg.JSON5,
+			majorByte != '"' && majorByte != '\'' && d.cfg.JSON5:
+			return true, fmt.Errorf("invalid char while expecting start of key: %s", byteToString(majorByte))
+		default:
+			_, err = d.stepHelper_acceptKey(majorByte, tokenSlot)
+		}
 		if err != nil {
 			return true, err
 		}
+		if d.cfg.RejectDuplicateKeys && tokenSlot.Type == TString {
+			if err := d.checkKey(tokenSlot.Str, offset); err != nil {
+				return true, err
+			}
+		}
 		// Now scan up to consume the colon as well, which is required next.
-		majorByte, err = readn1skippingWhitespace(d.r)
+		majorByte, err = d.readn1skippingWhitespace()
 		if err != nil {
 			return true, err
 		}
@@ -163,7 +327,7 @@ func (d *Decoder) step_acceptMapKeyOrBreak(tokenSlot *Token) (done bool, err err
 
 // Step in midst of decoding a map, value expected up next.
 func (d *Decoder) step_acceptMapValue(tokenSlot *Token) (done bool, err error) {
-	majorByte, err := readn1skippingWhitespace(d.r)
+	majorByte, err := d.readn1skippingWhitespace()
 	if err != nil {
 		return true, err
 	}
@@ -181,10 +345,26 @@ func (d *Decoder) stepHelper_acceptKey(majorByte byte, tokenSlot *Token) (done b
 }
 
 func (d *Decoder) stepHelper_acceptKV(t string, majorByte byte, tokenSlot *Token) (done bool, err error) {
+	d.tokenStart = d.r.NumRead() - 1
+	if d.cfg.JSON5 {
+		switch majorByte {
+		case '\'':
+			tokenSlot.Type = TString
+			tokenSlot.Str, err = d.decodeString(strscan_single, parseStringJSON5)
+			return true, err
+		case '"':
+			tokenSlot.Type = TString
+			tokenSlot.Str, err = d.decodeString(strscan_double5, parseStringJSON5)
+			return true, err
+		case '+', '-', '.', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', 'I', 'N':
+			return true, d.decodeNumberJSON5(tokenSlot)
+		}
+	}
 	switch majorByte {
 	case '{':
 		tokenSlot.Type = TMapOpen
 		tokenSlot.Length = -1
+		d.pushKeys()
 		d.pushPhase(d.step_acceptMapKeyOrBreak)
 		return false, nil
 	case '[':
@@ -193,35 +373,81 @@ func (d *Decoder) stepHelper_acceptKV(t string, majorByte byte, tokenSlot *Token
 		d.pushPhase(d.step_acceptArrValueOrBreak)
 		return false, nil
 	case 'n':
-		d.r.Readnzc(3) // FIXME must check these equal "ull"!
 		tokenSlot.Type = TNull
-		return true, nil
+		return true, d.readLiteral("null")
 	case '"':
 		tokenSlot.Type = TString
-		tokenSlot.Str, err = d.decodeString()
+		tokenSlot.Str, err = d.decodeString(strscan_normal, parseString)
 		return true, err
 	case 'f':
-		d.r.Readnzc(4) // FIXME must check these equal "alse"!
 		tokenSlot.Type = TBool
 		tokenSlot.Bool = false
-		return true, nil
+		return true, d.readLiteral("false")
 	case 't':
-		d.r.Readnzc(3) // FIXME must check these equal "rue"!
 		tokenSlot.Type = TBool
 		tokenSlot.Bool = true
-		return true, nil
+		return true, d.readLiteral("true")
 	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		// Some kind of numeric... but in json, we *can't tell* if it's float or int.
 		// JSON in general doesn't differentiate.  But we usually try to anyway.
 		// (If this results in us yielding an int, and an obj.Unmarshaller is filling a float,
 		// it's the Unmarshaller responsibility to decide to cast that.)
-		tokenSlot.Type, tokenSlot.Int, tokenSlot.Float64, err = d.decodeNumber(majorByte)
-		return true, err
+		return true, d.decodeNumber(majorByte, tokenSlot)
 	default:
 		return true, fmt.Errorf("invalid char while expecting start of %s: %s", t, byteToString(majorByte))
 	}
 }
 
+// Consumes the rest of a keyword literal (the first byte has already been eaten),
+// checking that it's spelled out in full.
+func (d *Decoder) readLiteral(lit string) error {
+	bs, err := d.r.Readnzc(len(lit) - 1)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	if string(bs) != lit[1:] {
+		return fmt.Errorf("invalid literal; expected %q", lit)
+	}
+	return nil
+}
+
+// Pushes a fresh set for the keys of a map we're entering, if rejecting duplicate keys.
+func (d *Decoder) pushKeys() {
+	if !d.cfg.RejectDuplicateKeys {
+		return
+	}
+	kl := len(d.keys)
+	if kl < cap(d.keys) {
+		d.keys = d.keys[:kl+1]
+		for k := range d.keys[kl] {
+			delete(d.keys[kl], k)
+		}
+	} else {
+		d.keys = append(d.keys, map[string]struct{}{})
+	}
+}
+
+// Pops the set of keys for a map we're leaving, if rejecting duplicate keys.
+func (d *Decoder) popKeys() {
+	if !d.cfg.RejectDuplicateKeys {
+		return
+	}
+	d.keys = d.keys[0 : len(d.keys)-1]
+}
+
+// Records a key in the set for the current map, erroring if it's already there.
+func (d *Decoder) checkKey(k string, offset int) error {
+	seen := d.keys[len(d.keys)-1]
+	if _, exists := seen[k]; exists {
+		return ErrDuplicateKey{k, offset}
+	}
+	seen[k] = struct{}{}
+	return nil
+}
+
 var byteToStringMap = map[byte]string{
 	',': "comma",
 	':': "colon",
@@ -8,7 +8,10 @@ package json
 import (
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"unicode"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -16,12 +19,15 @@ import (
 	"github.com/polydawn/refmt/tok"
 )
 
-func (d *Decoder) decodeString() (string, error) {
+// Decodes a string, using the given scan step to start, and parse func to finish:
+// strscan_normal and parseString for a JSON string, or strscan_single or
+// strscan_double5 and parseStringJSON5 for a JSON5 one.
+func (d *Decoder) decodeString(step strscanStep, parse func([]byte) ([]byte, bool)) (string, error) {
 	// First quote has already been eaten.
 	// Start tracking the byte slice; real string starts here.
 	d.r.Track()
 	// Scan until scanner tells us end of string.
-	for step := strscan_normal; step != nil; {
+	for step != nil {
 		majorByte, err := d.r.Readn1()
 		if err != nil {
 			return "", err
@@ -30,14 +36,22 @@ func (d *Decoder) decodeString() (string, error) {
 		if err != nil {
 			return "", err
 		}
+		if majorByte == '\n' { // Only possible in an escaped line break.
+			d.line++
+			d.lineStart = d.r.NumRead()
+		}
 	}
 	// Unread one.  The scan loop consumed the trailing quote already,
 	// which we don't want to pass onto the parser.
 	d.r.Unreadn1()
+	raw := d.r.StopTrack()
+	if d.cfg.Strict && !utf8.Valid(raw) {
+		return "", fmt.Errorf("invalid UTF-8 in string literal")
+	}
 	// Parse!
-	s, ok := parseString(d.r.StopTrack())
+	s, ok := parse(raw)
 	if !ok {
-		//return string(s), fmt.Errorf("string parse misc fail")
+		return "", fmt.Errorf("invalid escape sequence in string literal")
 	}
 	// Swallow the trailing quote again.
 	d.r.Readn1()
@@ -106,6 +120,73 @@ func strscan_escU123(c byte) (strscanStep, error) {
 	return nil, fmt.Errorf("invalid byte in \\u hexadecimal character escape: 0x%x", c)
 }
 
+// "single" is the state during a single-quoted string, as allowed by JSON5.
+func strscan_single(c byte) (strscanStep, error) {
+	return strscan_json5(c, '\'', strscan_single, strscan_singleEsc)
+}
+
+// "singleEsc" is the state after reading `'\` during a single-quoted string.
+func strscan_singleEsc(c byte) (strscanStep, error) {
+	return strscan_json5Esc(c, strscan_single, strscan_singleEscCR)
+}
+
+// "singleEscCR" is the state after reading `'\` and a carriage return during a single-quoted string.
+func strscan_singleEscCR(c byte) (strscanStep, error) {
+	if c == '\n' {
+		return strscan_single, nil
+	}
+	return strscan_single(c)
+}
+
+// "double5" is the state during a double-quoted string in JSON5.
+// It differs from "normal" only in allowing JSON5's escapes.
+func strscan_double5(c byte) (strscanStep, error) {
+	return strscan_json5(c, '"', strscan_double5, strscan_double5Esc)
+}
+
+// "double5Esc" is the state after reading `"\` during a double-quoted string in JSON5.
+func strscan_double5Esc(c byte) (strscanStep, error) {
+	return strscan_json5Esc(c, strscan_double5, strscan_double5EscCR)
+}
+
+// "double5EscCR" is the state after reading `"\` and a carriage return during a double-quoted string in JSON5.
+func strscan_double5EscCR(c byte) (strscanStep, error) {
+	if c == '\n' {
+		return strscan_double5, nil
+	}
+	return strscan_double5(c)
+}
+
+// Shared by the JSON5 string states: steps the string closed by `quote`.
+func strscan_json5(c byte, quote byte, self, esc strscanStep) (strscanStep, error) {
+	if c == quote { // done!
+		return nil, nil
+	}
+	if c == '\\' {
+		return esc, nil
+	}
+	if c < 0x20 { // Unprintable bytes are invalid in a json string.
+		return nil, fmt.Errorf("invalid unprintable byte in string literal: 0x%x", c)
+	}
+	return self, nil
+}
+
+// Shared by the JSON5 string states: steps the byte after a backslash.
+// JSON5 allows escaping any character, so rather than check escapes here,
+// this just skips over them, leaving it to 'parseStringJSON5()' to reject what it can't handle --
+// except that an escaped line break may be CRLF, which needs a state of its own.
+func strscan_json5Esc(c byte, normal, escCR strscanStep) (strscanStep, error) {
+	switch {
+	case c == '\r':
+		return escCR, nil
+	case c == '\n':
+		return normal, nil
+	case c < 0x20:
+		return nil, fmt.Errorf("invalid byte in string escape sequence: 0x%x", c)
+	}
+	return normal, nil
+}
+
 // Convert a json string byte sequence that is a complete string (quotes from
 // the outside dropped) bytes ready to be flipped into a go string.
 func parseString(s []byte) (t []byte, ok bool) {
@@ -115,7 +196,7 @@ func parseString(s []byte) (t []byte, ok bool) {
 	r := 0
 	for r < len(s) {
 		c := s[r]
-		if c == '\\' || c == '"' || c < ' ' {
+		if c == '\\' || c < ' ' {
 			break
 		}
 		if c < utf8.RuneSelf {
@@ -197,8 +278,9 @@ func parseString(s []byte) (t []byte, ok bool) {
 				w += utf8.EncodeRune(b[w:], rr)
 			}
 
-		// Quote, control characters are invalid.
-		case c == '"', c < ' ':
+		// Control characters are invalid.
+		// (Quotes can't turn up unescaped: the scanner ends the string at the closing one.)
+		case c < ' ':
 			return
 
 		// ASCII
@@ -217,6 +299,98 @@ func parseString(s []byte) (t []byte, ok bool) {
 	return b[0:w], true
 }
 
+// Like parseString, but for a JSON5 string, which may also use the escapes
+// `\'`, `\v`, `\0`, and `\xXX`; an escaped line break, which continues the
+// string without including the line break; and a backslash before any other
+// character (except a digit), which stands for the character itself.
+func parseStringJSON5(s []byte) (t []byte, ok bool) {
+	b := make([]byte, 0, len(s))
+	var rbuf [utf8.UTFMax]byte
+	for r := 0; r < len(s); {
+		c := s[r]
+		if c < ' ' { // Control characters are invalid, unescaped.
+			return
+		}
+		if c != '\\' {
+			// Coerce to well-formed UTF-8.
+			rr, size := utf8.DecodeRune(s[r:])
+			r += size
+			b = append(b, rbuf[:utf8.EncodeRune(rbuf[:], rr)]...)
+			continue
+		}
+		r++
+		if r >= len(s) {
+			return
+		}
+		switch c := s[r]; c {
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'v':
+			b = append(b, '\v')
+		case '0':
+			if r+1 < len(s) && '0' <= s[r+1] && s[r+1] <= '9' {
+				return // Octal escapes aren't allowed.
+			}
+			b = append(b, 0)
+		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			return
+		case 'x':
+			if r+3 > len(s) {
+				return
+			}
+			x, err := strconv.ParseUint(string(s[r+1:r+3]), 16, 8)
+			if err != nil {
+				return
+			}
+			b = append(b, rbuf[:utf8.EncodeRune(rbuf[:], rune(x))]...)
+			r += 2
+		case 'u':
+			rr := getu4(s[r-1:])
+			if rr < 0 {
+				return
+			}
+			r += 4
+			if utf16.IsSurrogate(rr) {
+				if dec := utf16.DecodeRune(rr, getu4(s[r+1:])); dec != unicode.ReplacementChar {
+					// A valid pair; consume.
+					r += 6
+					rr = dec
+				} else {
+					// Invalid surrogate; fall back to replacement rune.
+					rr = unicode.ReplacementChar
+				}
+			}
+			b = append(b, rbuf[:utf8.EncodeRune(rbuf[:], rr)]...)
+		case '\r':
+			// A line continuation: skip it, and the LF of a CRLF.
+			if r+1 < len(s) && s[r+1] == '\n' {
+				r++
+			}
+		case '\n':
+			// A line continuation: skip it.
+		default:
+			if c < ' ' {
+				return
+			}
+			rr, size := utf8.DecodeRune(s[r:])
+			r += size - 1
+			if rr != '\u2028' && rr != '\u2029' { // Those are line continuations too.
+				b = append(b, rbuf[:utf8.EncodeRune(rbuf[:], rr)]...)
+			}
+		}
+		r++
+	}
+	return b, true
+}
+
 // getu4 decodes \uXXXX from the beginning of s, returning the hex value,
 // or it returns -1.
 func getu4(s []byte) rune {
@@ -230,9 +404,12 @@ func getu4(s []byte) rune {
 	return rune(r)
 }
 
-// Returns *either* an int or a float -- json is ambigous.
+// Fills the token with *either* an int or a float -- json is ambigous.
 // An int is preferred if possible.
-func (d *Decoder) decodeNumber(majorByte byte) (tok.TokenType, int64, float64, error) {
+// Numbers which don't fit in an int64 are yielded as a uint64 if they can be,
+// and as a big.Int or big.Float otherwise.
+// If configured for lossless numbers, the text is yielded as is, instead.
+func (d *Decoder) decodeNumber(majorByte byte, tokenSlot *tok.Token) error {
 	// First byte has already been eaten.
 	// Easiest to unread1, so we can use track, then swallow it again.
 	d.r.Unreadn1()
@@ -251,15 +428,25 @@ func (d *Decoder) decodeNumber(majorByte byte) (tok.TokenType, int64, float64, e
 	default:
 		panic("unreachable")
 	}
+	last := majorByte
 	for {
 		b, err := d.r.Readn1()
 		if err == io.EOF {
+			// The input may end the number, but only after a digit:
+			// every other byte in a number needs something after it.
+			if last < '0' || last > '9' {
+				return io.ErrUnexpectedEOF
+			}
 			break
 		}
 		if err != nil {
-			return 0, 0, 0, err
+			return err
 		}
+		last = b
 		step, err = step(b)
+		if err != nil {
+			return err
+		}
 		if step == nil {
 			// Unread one.  The scan loop consumed one char beyond the end
 			// (this is necessary in json!),
@@ -267,22 +454,15 @@ func (d *Decoder) decodeNumber(majorByte byte) (tok.TokenType, int64, float64, e
 			d.r.Unreadn1()
 			break
 		}
-		if err != nil {
-			return 0, 0, 0, err
-		}
 	}
 	// Parse!
 	// *This is not a fast parse*.
-	// Try int first; if it fails for range reasons, halt; otherwise,
-	// then try float; if that fails return the float error.
-	s := string(d.r.StopTrack())
-	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
-		return tok.TInt, i, 0, nil
-	} else if err.(*strconv.NumError).Err == strconv.ErrRange {
-		return tok.TInt, i, 0, err
+	tokenSlot.Type = tok.TNumber
+	tokenSlot.Str = string(d.r.StopTrack())
+	if d.cfg.LosslessNumbers {
+		return nil
 	}
-	f, err := strconv.ParseFloat(s, 64)
-	return tok.TFloat64, 0, f, err
+	return tok.NormalizeNumber(tokenSlot)
 }
 
 // Scan steps are looped over the stream to find how long the number is.
@@ -318,6 +498,9 @@ func numscan_0(c byte) (numscanStep, error) {
 	if c == 'e' || c == 'E' {
 		return numscan_e, nil
 	}
+	if '0' <= c && c <= '9' {
+		return nil, fmt.Errorf("invalid leading zero in numeric literal")
+	}
 	return nil, nil
 }
 
@@ -369,3 +552,124 @@ func numscan_e0(c byte) (numscanStep, error) {
 	}
 	return nil, nil
 }
+
+// Reports whether the byte can start a bare identifier, which JSON5 allows as a map key.
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '$'
+}
+
+// Reports whether the byte can continue a bare identifier.
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9'
+}
+
+// Decodes a bare identifier.
+// Only ASCII identifiers are supported; JSON5 allows more, but they're rare.
+func (d *Decoder) decodeIdentifier() (string, error) {
+	// First byte has already been eaten.
+	// Easiest to unread1, so we can use track, then swallow it again.
+	d.r.Unreadn1()
+	d.r.Track()
+	d.r.Readn1()
+	if err := d.scanWord(isIdentPart); err != nil {
+		return "", err
+	}
+	return string(d.r.StopTrack()), nil
+}
+
+// Reads bytes for as long as the given func accepts them,
+// leaving the first one it doesn't unread.  The end of input is fine.
+func (d *Decoder) scanWord(accept func(byte) bool) error {
+	for {
+		b, err := d.r.Readn1()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !accept(b) {
+			d.r.Unreadn1()
+			return nil
+		}
+	}
+}
+
+// Reports whether the byte can be part of a number, in any of the forms JSON5 allows.
+// This is deliberately loose: the whole word is checked once it's been read.
+func isNumberPart(c byte) bool {
+	return isIdentPart(c) || c == '.' || c == '+' || c == '-'
+}
+
+// Fills the token with a number in any of the forms JSON5 allows:
+// anything decodeNumber accepts; those with a leading plus sign, or with a
+// leading or trailing decimal point; hexadecimal integers; and Infinity and NaN.
+// Infinity and NaN are always yielded as floats, even with lossless numbers;
+// hexadecimal integers become the equivalent decimal, so they're rejected with
+// lossless numbers, which promise to keep the text as it was.
+func (d *Decoder) decodeNumberJSON5(tokenSlot *tok.Token) error {
+	// First byte has already been eaten.
+	// Easiest to unread1, so we can use track, then swallow it again.
+	d.r.Unreadn1()
+	d.r.Track()
+	d.r.Readn1()
+	if err := d.scanWord(isNumberPart); err != nil {
+		return err
+	}
+	word := string(d.r.StopTrack())
+
+	// Split off the sign, so there's only one place to look for the rest.
+	sign, body := "", word
+	if body[0] == '+' || body[0] == '-' {
+		sign, body = body[:1], body[1:]
+	}
+	switch {
+	case body == "Infinity":
+		tokenSlot.Type = tok.TFloat64
+		tokenSlot.Float64 = math.Inf(1)
+		if sign == "-" {
+			tokenSlot.Float64 = math.Inf(-1)
+		}
+		return nil
+	case body == "NaN":
+		tokenSlot.Type = tok.TFloat64
+		tokenSlot.Float64 = math.NaN()
+		return nil
+	case len(body) > 2 && body[0] == '0' && (body[1] == 'x' || body[1] == 'X'):
+		if d.cfg.LosslessNumbers {
+			return fmt.Errorf("hexadecimal number %q can't be kept as text with lossless numbers, since JSON has no hexadecimal form", word)
+		}
+		// Check the digits first: SetString would also take a sign.
+		for _, c := range []byte(body[2:]) {
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return tok.ErrInvalidNumber{word}
+			}
+		}
+		bi, _ := new(big.Int).SetString(body[2:], 16) // Can't fail: already checked.
+		body = bi.String()
+	default:
+		// Pad a leading or trailing decimal point out with a zero,
+		// as long as there's a digit on the other side of it.
+		if i := strings.IndexByte(body, '.'); i >= 0 {
+			digitBefore := i > 0
+			digitAfter := i+1 < len(body) && '0' <= body[i+1] && body[i+1] <= '9'
+			if !digitBefore && digitAfter {
+				body = "0" + body
+			} else if digitBefore && !digitAfter {
+				body = body[:i+1] + "0" + body[i+1:]
+			}
+		}
+	}
+	if sign == "-" {
+		body = sign + body
+	}
+	if !tok.Number(body).IsValid() {
+		return tok.ErrInvalidNumber{word}
+	}
+	tokenSlot.Type = tok.TNumber
+	tokenSlot.Str = body
+	if d.cfg.LosslessNumbers {
+		return nil
+	}
+	return tok.NormalizeNumber(tokenSlot)
+}
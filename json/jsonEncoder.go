@@ -66,7 +66,11 @@ func (d *Encoder) Step(tok *Token) (done bool, err error) {
 			return true, fmt.Errorf("unexpected arrClose; expected start of value")
 		default:
 			// It's a value; handle it.
-			return true, d.flushValue(tok)
+			if err := d.flushValue(tok); err != nil {
+				return true, err
+			}
+			d.wr.Write(d.cfg.Terminator)
+			return true, nil
 		}
 	case phase_mapExpectKeyOrEnd:
 		switch tok.Type {
@@ -164,6 +168,7 @@ func (d *Encoder) popPhase() (bool, error) {
 	n := len(d.stack) - 1
 	if n == 0 {
 		d.wr.Write(d.cfg.Line)
+		d.wr.Write(d.cfg.Terminator)
 		return true, nil
 	}
 	if n < 0 { // the state machines are supposed to have already errored better
@@ -211,6 +216,18 @@ func (d *Encoder) flushValue(tok *Token) error {
 		return nil
 	case TFloat64:
 		return d.emitFloat(tok.Float64)
+	case TBigInt:
+		b := tok.BigInt.Append(d.scratch[:0], 10)
+		d.wr.Write(b)
+		return nil
+	case TBigFloat:
+		return d.emitBigFloat(tok.BigFloat)
+	case TNumber:
+		if !Number(tok.Str).IsValid() {
+			return ErrInvalidNumber{tok.Str}
+		}
+		d.wr.Write([]byte(tok.Str))
+		return nil
 	case TNull:
 		d.wr.Write(wordNull)
 		return nil
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"strconv"
 	"unicode/utf8"
 )
@@ -115,3 +116,13 @@ func (d *Encoder) emitFloat(f float64) error {
 	d.wr.Write(b)
 	return nil
 }
+
+// Emits a big.Float with as many digits as needed to represent it exactly
+// at its own precision.
+func (d *Encoder) emitBigFloat(f *big.Float) error {
+	if f.IsInf() {
+		return fmt.Errorf("unsupported value: %s", f.Text('g', -1))
+	}
+	d.wr.Write(f.Append(d.scratch[:0], 'g', -1))
+	return nil
+}
@@ -0,0 +1,102 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func testConformance(t *testing.T) {
+	t.Run("conformance suite", func(t *testing.T) {
+		for _, tc := range fixtures.JsonConformance {
+			tc := tc
+			t.Run(tc.Name, func(t *testing.T) {
+				if tc.Accept() {
+					seq := fixtures.Sequence{Title: tc.Name, Tokens: tc.Tokens}
+					checkDecodingWithOptions(t, DecodeOptions{Strict: true}, seq, tc.Input, nil)
+					return
+				}
+				if err := decodeAll(DecodeOptions{Strict: true}, tc.Input); err == nil {
+					t.Errorf("expected %q to be rejected, but it was accepted", tc.Input)
+				}
+			})
+		}
+	})
+	t.Run("literals are checked even when not strict", func(t *testing.T) {
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TNull}}}, `nope`,
+			ErrSyntax{3, 1, 4, fmt.Errorf(`invalid literal; expected "null"`)})
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TBool, Bool: true}}}, `tru`,
+			io.ErrUnexpectedEOF)
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{
+			{Type: TArrOpen, Length: -1},
+			{Type: TBool, Bool: false},
+		}}, `[fakse]`,
+			ErrSyntax{5, 1, 6, fmt.Errorf(`invalid literal; expected "false"`)})
+	})
+	t.Run("numbers are checked even when not strict", func(t *testing.T) {
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `012`,
+			ErrSyntax{1, 1, 2, fmt.Errorf("invalid leading zero in numeric literal")})
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `1.`,
+			io.ErrUnexpectedEOF)
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `1e+`,
+			io.ErrUnexpectedEOF)
+		checkDecodingWithOptions(t, DecodeOptions{LosslessNumbers: true}, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `-`,
+			io.ErrUnexpectedEOF)
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `1.x`,
+			ErrSyntax{2, 1, 3, fmt.Errorf("invalid byte after decimal in numeric literal: 0x78")})
+	})
+	t.Run("composite map keys are rejected even when not strict", func(t *testing.T) {
+		checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TMapOpen, Length: -1}, {}}}, `{[]:1}`,
+			ErrSyntax{1, 1, 2, fmt.Errorf("invalid char while expecting start of key: array open")})
+	})
+	t.Run("strict rejects trailing data", func(t *testing.T) {
+		checkDecodingWithOptions(t, DecodeOptions{Strict: true}, fixtures.Sequence{Tokens: fixtures.Tokens{
+			{Type: TArrOpen, Length: -1},
+			{Type: TInt, Int: 1},
+			{Type: TArrClose},
+		}}, "[1] \n x",
+			ErrSyntax{6, 2, 2, fmt.Errorf("unexpected data after top-level value: 0x78")})
+		t.Run("but not trailing whitespace", func(t *testing.T) {
+			checkDecodingWithOptions(t, DecodeOptions{Strict: true}, fixtures.SequenceMap["true"], "true \n", nil)
+		})
+		t.Run("which is otherwise left unread", func(t *testing.T) {
+			checkDecoding(t, fixtures.SequenceMap["true"], "true x", nil)
+		})
+	})
+	t.Run("strict rejects trailing commas", func(t *testing.T) {
+		checkDecodingWithOptions(t, DecodeOptions{Strict: true}, fixtures.Sequence{Tokens: fixtures.Tokens{
+			{Type: TArrOpen, Length: -1},
+			TokStr("value"),
+			{}, // the last token slot is touched before the error.
+		}}, `["value",]`,
+			ErrSyntax{9, 1, 10, fmt.Errorf("trailing comma before array close")})
+	})
+	t.Run("strict rejects invalid utf8", func(t *testing.T) {
+		checkDecodingWithOptions(t, DecodeOptions{Strict: true}, fixtures.Sequence{Tokens: fixtures.Tokens{
+			TokStr(""),
+		}}, "\"a\xffb\"",
+			ErrSyntax{3, 1, 4, fmt.Errorf("invalid UTF-8 in string literal")})
+		t.Run("which is otherwise replaced", func(t *testing.T) {
+			checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{TokStr("a�b")}}, "\"a\xffb\"", nil)
+		})
+	})
+}
+
+// Runs the decoder over the whole input, returning the first error, if any.
+func decodeAll(cfg DecodeOptions, serial string) error {
+	dec := NewDecoder(cfg, bytes.NewBufferString(serial))
+	var tok Token
+	for {
+		done, err := dec.Step(&tok)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
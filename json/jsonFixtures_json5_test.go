@@ -0,0 +1,160 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func testJSON5(t *testing.T) {
+	json5 := DecodeOptions{JSON5: true}
+	t.Run("comments", func(t *testing.T) {
+		seq := fixtures.SequenceMap["single row map"]
+		checkDecodingWithOptions(t, json5, seq, "// leading\n{ /* inline */ \"key\" : // line\n \"value\" }", nil)
+		checkDecodingWithOptions(t, json5, seq, "{\"key\":\"value\"} /* trailing \n block */ // and line", nil)
+		t.Run("are rejected unless json5", func(t *testing.T) {
+			checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `/**/1`,
+				ErrSyntax{0, 1, 1, fmt.Errorf("invalid char while expecting start of value: 0x2f")})
+		})
+		t.Run("unterminated", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `/* forever`, io.ErrUnexpectedEOF)
+		})
+		t.Run("with a lone slash", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `/x`,
+				ErrSyntax{1, 1, 2, fmt.Errorf("invalid char after slash; expected comment: 0x78")})
+		})
+		t.Run("counted in line numbers", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, "/*\n\n*/ //\n  x",
+				ErrSyntax{12, 4, 3, fmt.Errorf("invalid char while expecting start of value: 0x78")})
+		})
+	})
+	t.Run("trailing commas", func(t *testing.T) {
+		checkDecodingWithOptions(t, DecodeOptions{JSON5: true, Strict: true}, fixtures.SequenceMap["duo entry array"], `["value","v2",]`, nil)
+		checkDecodingWithOptions(t, DecodeOptions{JSON5: true, Strict: true}, fixtures.SequenceMap["duo row map"], `{"key":"value","k2":"v2",}`, nil)
+	})
+	t.Run("identifier keys", func(t *testing.T) {
+		checkDecodingWithOptions(t, json5, fixtures.SequenceMap["duo row map"], `{key:"value", k2 :"v2"}`, nil)
+		checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{
+			{Type: TMapOpen, Length: -1},
+			TokStr("$_a1"), {Type: TNull},
+			TokStr("null"), {Type: TBool, Bool: true},
+			{Type: TMapClose},
+		}}, `{$_a1:null,null:true}`, nil)
+		t.Run("but not other bare keys", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TMapOpen, Length: -1}, {}}}, `{1:2}`,
+				ErrSyntax{1, 1, 2, fmt.Errorf("invalid char while expecting start of key: 0x31")})
+		})
+	})
+	t.Run("single quoted strings", func(t *testing.T) {
+		checkDecodingWithOptions(t, json5, fixtures.SequenceMap["single row map"], `{'key':'value'}`, nil)
+		checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{TokStr(`it's "quoted"` + "\né")}}, `'it\'s "quoted"\né'`, nil)
+	})
+	t.Run("string escapes", func(t *testing.T) {
+		for _, tr := range []struct {
+			serial string
+			expect string
+		}{
+			{`'it\'s'`, "it's"},
+			{`"it\'s"`, "it's"},
+			{`'\"\\\/\b\f\n\r\t\v'`, "\"\\/\b\f\n\r\t\v"},
+			{`'\0'`, "\x00"},
+			{`'\x41\xe9'`, "Aé"},
+			{`'\u00e9\ud834\udd1e'`, "é𝄞"},
+			{`'\a\z\é'`, "azé"},
+			{"'a\\\nb'", "ab"},
+			{"'a\\\r\nb'", "ab"},
+			{"'a\\\rb'", "ab"},
+			{"'a\\\u2028b\\\u2029c'", "abc"},
+		} {
+			t.Run(tr.serial, func(t *testing.T) {
+				checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{TokStr(tr.expect)}}, tr.serial, nil)
+			})
+		}
+		t.Run("which are still invalid", func(t *testing.T) {
+			for _, serial := range []string{`'\1'`, `'\01'`, `'\x4'`, `'\xg0'`, `'\u12'`} {
+				checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{TokStr("")}}, serial,
+					ErrSyntax{len(serial) - 2, 1, len(serial) - 1, fmt.Errorf("invalid escape sequence in string literal")})
+			}
+		})
+		t.Run("which are rejected unless json5", func(t *testing.T) {
+			checkDecoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{TokStr("")}}, `"it\'s"`,
+				ErrSyntax{4, 1, 5, fmt.Errorf("invalid byte in string escape sequence: 0x27")})
+		})
+		t.Run("with line continuations counted in line numbers", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{
+				{Type: TArrOpen, Length: -1},
+				TokStr("ab"),
+				{},
+			}}, "['a\\\nb' x]",
+				ErrSyntax{8, 2, 4, fmt.Errorf("expected comma or array close after array value; got 0x78")})
+		})
+	})
+	t.Run("numbers", func(t *testing.T) {
+		for _, tr := range []struct {
+			serial string
+			expect Token
+		}{
+			{`0x1F`, Token{Type: TInt, Int: 31}},
+			{`-0XfF`, Token{Type: TInt, Int: -255}},
+			{`0x1FFFFFFFFFFFFFFFF`, Token{Type: TBigInt, BigInt: mustBigInt("36893488147419103231")}},
+			{`+1`, Token{Type: TInt, Int: 1}},
+			{`.5`, Token{Type: TFloat64, Float64: 0.5}},
+			{`5.`, Token{Type: TFloat64, Float64: 5}},
+			{`-5.e1`, Token{Type: TFloat64, Float64: -50}},
+			{`Infinity`, Token{Type: TFloat64, Float64: math.Inf(1)}},
+			{`-Infinity`, Token{Type: TFloat64, Float64: math.Inf(-1)}},
+			{`1e3`, Token{Type: TFloat64, Float64: 1000}},
+		} {
+			t.Run(tr.serial, func(t *testing.T) {
+				if tr.expect.Type == TBigInt {
+					// Too big for any fixed size type; go-cmp can't compare those, so compare the text.
+					var slot Token
+					_, err := NewDecoder(json5, bytes.NewBufferString(tr.serial)).Step(&slot)
+					Wish(t, err, ShouldEqual, nil)
+					Wish(t, slot.String(), ShouldEqual, tr.expect.String())
+					return
+				}
+				checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{tr.expect}}, tr.serial, nil)
+			})
+		}
+		t.Run("NaN", func(t *testing.T) {
+			var tok Token
+			done, err := NewDecoder(json5, bytes.NewBufferString(`NaN`)).Step(&tok)
+			Wish(t, done, ShouldEqual, true)
+			Wish(t, err, ShouldEqual, nil)
+			Wish(t, tok.Type, ShouldEqual, TFloat64)
+			Wish(t, math.IsNaN(tok.Float64), ShouldEqual, true)
+		})
+		t.Run("still rejects leading zeros", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{}}}, `012`,
+				ErrSyntax{2, 1, 3, ErrInvalidNumber{"012"}})
+		})
+		t.Run("rejects a bad hex number", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[0xG]`,
+				ErrSyntax{3, 1, 4, ErrInvalidNumber{"0xG"}})
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[0x-1]`,
+				ErrSyntax{4, 1, 5, ErrInvalidNumber{"0x-1"}})
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[0x+1]`,
+				ErrSyntax{4, 1, 5, ErrInvalidNumber{"0x+1"}})
+		})
+		t.Run("rejects hex with lossless numbers", func(t *testing.T) {
+			checkDecodingWithOptions(t, DecodeOptions{JSON5: true, LosslessNumbers: true}, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[0x1F]`,
+				ErrSyntax{4, 1, 5, fmt.Errorf(`hexadecimal number "0x1F" can't be kept as text with lossless numbers, since JSON has no hexadecimal form`)})
+		})
+		t.Run("rejects a decimal point without digits", func(t *testing.T) {
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[.]`,
+				ErrSyntax{1, 1, 2, ErrInvalidNumber{"."}})
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[-.]`,
+				ErrSyntax{2, 1, 3, ErrInvalidNumber{"-."}})
+			checkDecodingWithOptions(t, json5, fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TArrOpen, Length: -1}, {}}}, `[.e1]`,
+				ErrSyntax{3, 1, 4, ErrInvalidNumber{".e1"}})
+		})
+	})
+}
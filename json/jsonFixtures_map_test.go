@@ -1,8 +1,10 @@
 package json
 
 import (
+	"fmt"
 	"testing"
 
+	. "github.com/polydawn/refmt/tok"
 	"github.com/polydawn/refmt/tok/fixtures"
 )
 
@@ -41,4 +43,26 @@ func testMap(t *testing.T) {
 			checkDecoding(t, seq, `{"k2":"v2","key":"value"}`, nil)
 		})
 	})
+	t.Run("repeated key", func(t *testing.T) {
+		serial := `{"a":1, "b":{"a":2}, "a":3}`
+		seq := fixtures.Sequence{"repeated key", fixtures.Tokens{
+			{Type: TMapOpen}, TokStr("a"), TokInt(1),
+			TokStr("b"), {Type: TMapOpen}, TokStr("a"), TokInt(2), {Type: TMapClose},
+			TokStr("a"), TokInt(3),
+			{Type: TMapClose},
+		}}
+		t.Run("decode by default lets it pass", func(t *testing.T) {
+			checkDecoding(t, seq, serial, nil)
+		})
+		t.Run("decode rejecting duplicates errors", func(t *testing.T) {
+			seq.Tokens = seq.Tokens[:9]
+			checkDecodingWithOptions(t, DecodeOptions{RejectDuplicateKeys: true}, seq, serial, ErrDuplicateKey{"a", 21})
+		})
+	})
+	t.Run("syntax error reports position", func(t *testing.T) {
+		seq := fixtures.Sequence{"syntax error", fixtures.Tokens{
+			{Type: TMapOpen}, TokStr("a"), TokInt(1), TokStr("b"), {},
+		}}
+		checkDecoding(t, seq, "{\"a\":1,\n \"b\":x}", ErrSyntax{13, 2, 6, fmt.Errorf("invalid char while expecting start of value: 0x78")})
+	})
 }
@@ -1,9 +1,13 @@
 package json
 
 import (
-	"strconv"
+	"bytes"
+	"math"
+	"math/big"
 	"testing"
 
+	. "github.com/warpfork/go-wish"
+
 	. "github.com/polydawn/refmt/tok"
 	"github.com/polydawn/refmt/tok/fixtures"
 )
@@ -33,10 +37,68 @@ func testNumber(t *testing.T) {
 		seq := fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TFloat64, Float64: 1.0e+300}}}
 		checkCanonical(t, seq, `1e+300`)
 	})
-	t.Run("integer too big to parse", func(t *testing.T) {
-		seq := fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TInt, Int: 2<<62 - 1}}}
-		t.Run("decode", func(t *testing.T) {
-			checkDecoding(t, seq, `18446744073709551617`, &strconv.NumError{"ParseInt", "18446744073709551617", strconv.ErrRange})
+	t.Run("integer too big for int64", func(t *testing.T) {
+		seq := fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TUint, Uint: math.MaxUint64}}}
+		checkCanonical(t, seq, "18446744073709551615")
+	})
+	t.Run("integer too big for uint64", func(t *testing.T) {
+		checkBigNumber(t, Token{Type: TBigInt, BigInt: mustBigInt("18446744073709551617")}, "18446744073709551617")
+	})
+	t.Run("integer too negative for int64", func(t *testing.T) {
+		checkBigNumber(t, Token{Type: TBigInt, BigInt: mustBigInt("-9223372036854775809")}, "-9223372036854775809")
+	})
+	t.Run("float too big for float64", func(t *testing.T) {
+		bf, _, _ := big.ParseFloat("1e+400", 10, 24, big.ToNearestEven)
+		checkBigNumber(t, Token{Type: TBigFloat, BigFloat: bf}, "1e+400")
+	})
+	t.Run("float too small for float64", func(t *testing.T) {
+		bf, _, _ := big.ParseFloat("1e-400", 10, 24, big.ToNearestEven)
+		checkBigNumber(t, Token{Type: TBigFloat, BigFloat: bf}, "1e-400")
+	})
+	t.Run("lossless numbers", func(t *testing.T) {
+		cfg := DecodeOptions{LosslessNumbers: true}
+		for _, text := range []string{"1.0", "-0", "3.14159265358979323846264338327950288", "18446744073709551617", "1E+2"} {
+			t.Run(text, func(t *testing.T) {
+				seq := fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TNumber, Str: text}}}
+				checkDecodingWithOptions(t, cfg, seq, text, nil)
+				checkEncoding(t, seq, text, nil)
+			})
+		}
+		t.Run("in an array", func(t *testing.T) {
+			seq := fixtures.Sequence{Tokens: fixtures.Tokens{
+				{Type: TArrOpen, Length: 2},
+				{Type: TNumber, Str: "1.50"},
+				{Type: TNumber, Str: "2"},
+				{Type: TArrClose},
+			}}
+			checkDecodingWithOptions(t, cfg, seq, `[1.50,2]`, nil)
+		})
+		t.Run("invalid text won't encode", func(t *testing.T) {
+			seq := fixtures.Sequence{Tokens: fixtures.Tokens{{Type: TNumber, Str: "0x10"}}}
+			checkEncoding(t, seq, "", ErrInvalidNumber{"0x10"})
 		})
 	})
 }
+
+// Like checkCanonical, but for big numbers, which the usual deep equality can't see into;
+// decoded tokens are compared by their string form instead.
+func checkBigNumber(t *testing.T, tok Token, serial string) {
+	t.Run("encode canonical", func(t *testing.T) {
+		checkEncoding(t, fixtures.Sequence{Tokens: fixtures.Tokens{tok}}, serial, nil)
+	})
+	t.Run("decode canonical", func(t *testing.T) {
+		var slot Token
+		done, err := NewDecoder(DecodeOptions{}, bytes.NewBufferString(serial)).Step(&slot)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, done, ShouldEqual, true)
+		Wish(t, slot.String(), ShouldEqual, tok.String())
+	})
+}
+
+func mustBigInt(s string) *big.Int {
+	bi, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big int fixture: " + s)
+	}
+	return bi
+}
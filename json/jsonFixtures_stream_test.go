@@ -0,0 +1,103 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func testStream(t *testing.T) {
+	t.Run("decode newline-delimited values", func(t *testing.T) {
+		checkDecodingStream(t, "{\"key\":\"value\"}\n[\"value\"]\n\"s\"\n7\n", []fixtures.Sequence{
+			fixtures.SequenceMap["single row map"],
+			fixtures.SequenceMap["single entry array"],
+			{Tokens: fixtures.Tokens{TokStr("s")}},
+			{Tokens: fixtures.Tokens{{Type: TInt, Int: 7}}},
+		}, nil)
+	})
+	t.Run("decode values with no line breaks between", func(t *testing.T) {
+		checkDecodingStream(t, `[]{}"s"true`, []fixtures.Sequence{
+			fixtures.SequenceMap["empty array"],
+			fixtures.SequenceMap["empty map"],
+			{Tokens: fixtures.Tokens{TokStr("s")}},
+			fixtures.SequenceMap["true"],
+		}, nil)
+	})
+	t.Run("decode empty stream", func(t *testing.T) {
+		checkDecodingStream(t, "", nil, nil)
+		checkDecodingStream(t, " \n\n", nil, nil)
+	})
+	t.Run("decode stream with an incomplete value", func(t *testing.T) {
+		checkDecodingStream(t, "true\n[", []fixtures.Sequence{
+			fixtures.SequenceMap["true"],
+			fixtures.SequenceMap["dangling arr open"],
+		}, io.EOF)
+	})
+	t.Run("encode with terminator", func(t *testing.T) {
+		checkEncodingStream(t, EncodeOptions{Terminator: []byte{'\n'}}, []fixtures.Sequence{
+			fixtures.SequenceMap["single row map"],
+			fixtures.SequenceMap["single entry array"],
+			fixtures.SequenceMap["true"],
+		}, "{\"key\":\"value\"}\n[\"value\"]\ntrue\n")
+	})
+	t.Run("unmarshal each value", func(t *testing.T) {
+		u := NewUnmarshaller(bytes.NewBufferString("{\"a\":1}\n{\"a\":2}\n"))
+		var vs []map[string]int
+		for u.More() {
+			var v map[string]int
+			Wish(t, u.Unmarshal(&v), ShouldEqual, nil)
+			vs = append(vs, v)
+		}
+		Wish(t, vs, ShouldEqual, []map[string]int{{"a": 1}, {"a": 2}})
+	})
+}
+
+func checkDecodingStream(t *testing.T, serial string, expectSequences []fixtures.Sequence, expectErr error) {
+	t.Helper()
+	tokenSrc := NewDecoder(DecodeOptions{}, bytes.NewBufferString(serial))
+
+	// Decode values for as long as the decoder says there are more,
+	//  stopping at the first error.
+	//  As in checkDecoding, the token slot of a step which errored is recorded too.
+	var yields []fixtures.Tokens
+	var err error
+	for err == nil && tokenSrc.More() {
+		tokenSrc.Reset()
+		var yield fixtures.Tokens
+		for done := false; !done && err == nil; {
+			var tok Token
+			done, err = tokenSrc.Step(&tok)
+			yield = append(yield, tok)
+		}
+		yields = append(yields, yield)
+	}
+
+	var expectYields []fixtures.Tokens
+	for _, seq := range expectSequences {
+		expectYields = append(expectYields, seq.SansLengthInfo().Tokens)
+	}
+	Wish(t, yields, ShouldEqual, expectYields)
+	Wish(t, err, ShouldEqual, expectErr)
+}
+
+func checkEncodingStream(t *testing.T, cfg EncodeOptions, sequences []fixtures.Sequence, expectSerial string) {
+	t.Helper()
+	outputBuf := &bytes.Buffer{}
+	tokenSink := NewEncoder(outputBuf, cfg)
+	for _, seq := range sequences {
+		tokenSink.Reset()
+		for _, tok := range seq.Tokens {
+			done, err := tokenSink.Step(&tok)
+			Wish(t, err, ShouldEqual, nil)
+			if done {
+				break
+			}
+		}
+	}
+	Wish(t, outputBuf.String(), ShouldEqual, expectSerial)
+}
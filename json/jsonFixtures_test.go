@@ -22,6 +22,9 @@ func Test(t *testing.T) {
 	testArray(t)
 	testComposite(t)
 	testNumber(t)
+	testConformance(t)
+	testStream(t)
+	testJSON5(t)
 }
 
 func checkCanonical(t *testing.T, sequence fixtures.Sequence, serial string) {
@@ -60,13 +63,18 @@ func checkEncoding(t *testing.T, sequence fixtures.Sequence, expectSerial string
 }
 
 func checkDecoding(t *testing.T, expectSequence fixtures.Sequence, serial string, expectErr error) {
+	t.Helper()
+	checkDecodingWithOptions(t, DecodeOptions{}, expectSequence, serial, expectErr)
+}
+
+func checkDecodingWithOptions(t *testing.T, cfg DecodeOptions, expectSequence fixtures.Sequence, serial string, expectErr error) {
 	// Decoding JSON is *never* going to yield length info on tokens,
 	//  so we'll strip that here rather than forcing all our fixtures to say it.
 	expectSequence = expectSequence.SansLengthInfo()
 
 	t.Helper()
 	inputBuf := bytes.NewBufferString(serial)
-	tokenSrc := NewDecoder(inputBuf)
+	tokenSrc := NewDecoder(cfg, inputBuf)
 
 	// Run steps, advancing until the decoder reports it's done.
 	//  If the decoder keeps yielding more tokens than we expect, that's fine...
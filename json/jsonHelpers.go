@@ -74,8 +74,8 @@ func Unmarshal(data []byte, v interface{}) error {
 	return NewUnmarshaller(bytes.NewBuffer(data)).Unmarshal(v)
 }
 
-func UnmarshalAtlased(data []byte, v interface{}, atl atlas.Atlas) error {
-	return NewUnmarshallerAtlased(bytes.NewBuffer(data), atl).Unmarshal(v)
+func UnmarshalAtlased(cfg DecodeOptions, data []byte, v interface{}, atl atlas.Atlas) error {
+	return NewUnmarshallerAtlased(cfg, bytes.NewBuffer(data), atl).Unmarshal(v)
 }
 
 type Unmarshaller struct {
@@ -90,13 +90,19 @@ func (x *Unmarshaller) Unmarshal(v interface{}) error {
 	return x.pump.Run()
 }
 
+// More reports whether there's another value in the input to unmarshal.
+// Loop on it to unmarshal each value of a stream such as newline-delimited JSON in turn.
+func (x *Unmarshaller) More() bool {
+	return x.decoder.More()
+}
+
 func NewUnmarshaller(r io.Reader) *Unmarshaller {
-	return NewUnmarshallerAtlased(r, atlas.MustBuild())
+	return NewUnmarshallerAtlased(DecodeOptions{}, r, atlas.MustBuild())
 }
-func NewUnmarshallerAtlased(r io.Reader, atl atlas.Atlas) *Unmarshaller {
+func NewUnmarshallerAtlased(cfg DecodeOptions, r io.Reader, atl atlas.Atlas) *Unmarshaller {
 	x := &Unmarshaller{
-		unmarshaller: obj.NewUnmarshaller(atl),
-		decoder:      NewDecoder(r),
+		unmarshaller: obj.NewUnmarshaller(atl, obj.UnmarshalOptions{IgnoreUnknownFields: cfg.IgnoreUnknownFields}),
+		decoder:      NewDecoder(cfg, r),
 	}
 	x.pump = shared.TokenPump{
 		x.decoder,
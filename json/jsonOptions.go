@@ -8,6 +8,12 @@ type EncodeOptions struct {
 	// If set, this will be prefixed $N$ times before each line's content to pretty-print.
 	// (Likely values are a tab, or a few spaces.)
 	Indent []byte
+
+	// If set, this is written after each complete top-level value.
+	// Set it to `[]byte{'\n'}` (leaving Line and Indent unset) to emit
+	// newline-delimited JSON: one value per line, as many values as are
+	// marshalled to the same Marshaller.
+	Terminator []byte
 }
 
 // marker method -- you may use this type to instruct `refmt.Marshal`
@@ -15,7 +21,44 @@ type EncodeOptions struct {
 func (EncodeOptions) IsEncodeOptions() {}
 
 type DecodeOptions struct {
-	// future: options to validate canonical serial order
+	// If set, an object which repeats a key (compared after unescaping)
+	// is rejected with an ErrDuplicateKey.
+	RejectDuplicateKeys bool
+
+	// If set, numbers are yielded as TNumber tokens holding the exact text
+	// from the input, instead of being converted to an int or float.
+	// Unmarshalling into an `interface{}` then produces a `tok.Number`
+	// (also known as `refmt.Number`), which re-encodes exactly as it was;
+	// unmarshalling into fields of numeric types works as usual.
+	LosslessNumbers bool
+
+	// If set, the decoder rejects everything RFC 8259 doesn't allow,
+	// rather than tolerating the common slips it otherwise lets by:
+	// a trailing comma before an array or map close is an error;
+	// so is a map key which isn't a string;
+	// so are bytes in a string which aren't valid UTF-8 (otherwise they're
+	// replaced with U+FFFD);
+	// and so is anything but whitespace after the end of the top-level value.
+	// That last check means reading to the end of the input, so a Decoder
+	// configured this way can't be used for a stream of several values.
+	Strict bool
+
+	// If set, the decoder also accepts the JSON5 extensions which make JSON
+	// friendlier to write by hand: `//` and `/* */` comments; trailing
+	// commas; map keys written as bare (ASCII) identifiers; strings in
+	// single quotes, and the escape sequences JSON5 adds to JSON's (such as
+	// `\'`, `\x41`, and line breaks escaped to continue a string);
+	// numbers in hexadecimal, with a leading plus sign, or with a leading
+	// or trailing decimal point; and Infinity and NaN.
+	// (Not supported: JSON5's extra whitespace characters.)
+	// This may be combined with Strict, which then still rejects invalid
+	// UTF-8 and trailing data; and with LosslessNumbers, which then rejects
+	// hexadecimal numbers, since their text can't be kept as JSON.
+	JSON5 bool
+
+	// Passed on to the object unmarshaller by the Unmarshaller helpers;
+	// see `obj.UnmarshalOptions.IgnoreUnknownFields`.  The Decoder ignores it.
+	IgnoreUnknownFields bool
 }
 
 // marker method -- you may use this type to instruct `refmt.Marshal`
@@ -17,7 +17,7 @@ func Marshal(opts EncodeOptions, v interface{}) ([]byte, error) {
 	case json.EncodeOptions:
 		return json.MarshalAtlased(o2, v, atlas.MustBuild())
 	case cbor.EncodeOptions:
-		return cbor.MarshalAtlased(v, atlas.MustBuild())
+		return cbor.MarshalAtlased(o2, v, atlas.MustBuild())
 	default:
 		panic("incorrect usage: unknown EncodeOptions type")
 	}
@@ -28,7 +28,7 @@ func MarshalAtlased(opts EncodeOptions, v interface{}, atl atlas.Atlas) ([]byte,
 	case json.EncodeOptions:
 		return json.MarshalAtlased(o2, v, atl)
 	case cbor.EncodeOptions:
-		return cbor.MarshalAtlased(v, atl)
+		return cbor.MarshalAtlased(o2, v, atl)
 	default:
 		panic("incorrect usage: unknown EncodeOptions type")
 	}
@@ -43,7 +43,7 @@ func NewMarshaller(opts EncodeOptions, wr io.Writer) Marshaller {
 	case json.EncodeOptions:
 		return json.NewMarshallerAtlased(wr, o2, atlas.MustBuild())
 	case cbor.EncodeOptions:
-		return cbor.NewMarshaller(wr)
+		return cbor.NewMarshallerAtlased(wr, o2, atlas.MustBuild())
 	default:
 		panic("incorrect usage: unknown EncodeOptions type")
 	}
@@ -54,7 +54,7 @@ func NewMarshallerAtlased(opts EncodeOptions, wr io.Writer, atl atlas.Atlas) Mar
 	case json.EncodeOptions:
 		return json.NewMarshallerAtlased(wr, o2, atl)
 	case cbor.EncodeOptions:
-		return cbor.NewMarshallerAtlased(wr, atl)
+		return cbor.NewMarshallerAtlased(wr, o2, atl)
 	default:
 		panic("incorrect usage: unknown EncodeOptions type")
 	}
@@ -0,0 +1,12 @@
+package refmt
+
+import (
+	"github.com/polydawn/refmt/tok"
+)
+
+// Number is a numeric literal kept as the text it was written as.
+// It's what unmarshalling into an `interface{}` produces for numbers when
+// decoding json with `json.DecodeOptions{LosslessNumbers: true}`,
+// and marshalling it emits that text back out exactly.
+// See `tok.Number` for the conversion methods.
+type Number = tok.Number
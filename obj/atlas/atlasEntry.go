@@ -90,8 +90,32 @@ type AtlasEntry struct {
 	// Only valid if `this.Type.Kind() == Interface`.
 	UnionKeyedMorphism *UnionKeyedMorphism
 
-	// FUTURE: enum-ish primitives, multiplexers for interfaces,
-	//  lots of such things will belong here.
+	// Configuration for how to pick concrete types to fill a union interface,
+	// when the type hint is an entry within the concrete value's own map.
+	// Only valid if `this.Type.Kind() == Interface`.
+	UnionInlineMorphism *UnionInlineMorphism
+
+	// Configuration for how to pick concrete types to fill a union interface,
+	// when the type hint is the tag on the concrete value.
+	// Only valid if `this.Type.Kind() == Interface`.
+	UnionTaggedMorphism *UnionTaggedMorphism
+
+	// Configuration for how to pick concrete types to fill a union interface,
+	// when the type hint and the concrete value are sibling entries in a map.
+	// Only valid if `this.Type.Kind() == Interface`.
+	UnionEnvelopeMorphism *UnionEnvelopeMorphism
+
+	// Configuration for how to pick concrete types to fill a union interface,
+	// when the type hint is simply what kind of token the value starts with.
+	// Only valid if `this.Type.Kind() == Interface`.
+	UnionKindedMorphism *UnionKindedMorphism
+
+	// Configuration for mapping a fixed set of values of a string or int kind
+	// to symbolic names.
+	// Only valid if `this.Type.Kind()` is a string or int kind.
+	EnumMorphism *EnumMorphism
+
+	// FUTURE: lots more such things will belong here.
 
 	// --------------------------------------------------------
 	// Hooks, validate helpers
@@ -13,14 +13,14 @@ func ExampleTime() {
 	msg, _ := json.MarshalAtlased(json.EncodeOptions{}, time.Date(2014, 12, 25, 1, 0, 0, 0, time.UTC), atl)
 	fmt.Printf("%s\n", msg)
 	var t1 time.Time
-	json.UnmarshalAtlased(msg, &t1, atl)
+	json.UnmarshalAtlased(json.DecodeOptions{}, msg, &t1, atl)
 	fmt.Printf("%s\n", t1)
 
 	atl, _ = atlas.Build(Time_AsRFC3339)
 	msg, _ = json.MarshalAtlased(json.EncodeOptions{}, time.Date(2014, 12, 25, 1, 0, 0, 0, time.UTC), atl)
 	fmt.Printf("%s\n", msg)
 	var t2 time.Time
-	json.UnmarshalAtlased(msg, &t2, atl)
+	json.UnmarshalAtlased(json.DecodeOptions{}, msg, &t2, atl)
 	fmt.Printf("%s\n", t2)
 
 	// Output:
@@ -0,0 +1,112 @@
+package atlas
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type EnumMorphism struct {
+	// Mapping of serial names to the values they represent.
+	Elements map[string]reflect.Value
+	// Mapping of values (see EnumKey) to serial names (roughly the dual of the Elements map).
+	Mappings map[interface{}]string
+	// Purely to have in readiness for error messaging.
+	KnownMembers []string
+
+	// The serial name to use for any value which isn't otherwise known.
+	Fallback string
+	// Flag for whether the Fallback feature should be used (empty string is a valid name).
+	UseFallback bool
+}
+
+/*
+	Configure a string or integer typedef to be handled as an enum:
+	each of a fixed set of values is serialized as a symbolic string,
+	and any other value is rejected.
+
+	For example:
+
+		atlas.BuildEntry(Color(0)).Enum().Of(map[string]Color{
+			"red":   Red,
+			"green": Green,
+		})
+
+	The same works for string types, in which case this restricts the set
+	of strings which are acceptable (and may also rename them).
+
+	Use `Fallback` to name a member which should be used in place of any
+	unrecognized value: unmarshalling an unknown string will yield the
+	fallback member's value, and marshalling an unknown value will emit the
+	fallback member's name.
+*/
+func (x *BuilderCore) Enum() *BuilderEnumMorphism {
+	switch x.entry.Type.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// pass
+	default:
+		panic(fmt.Errorf("cannot use enum morphisms for type %q, which is kind %s", x.entry.Type, x.entry.Type.Kind()))
+	}
+	x.entry.EnumMorphism = &EnumMorphism{
+		Elements: make(map[string]reflect.Value),
+		Mappings: make(map[interface{}]string),
+	}
+	return &BuilderEnumMorphism{x.entry}
+}
+
+type BuilderEnumMorphism struct {
+	entry *AtlasEntry
+}
+
+func (x *BuilderEnumMorphism) Fallback(name string) *BuilderEnumMorphism {
+	x.entry.EnumMorphism.Fallback = name
+	x.entry.EnumMorphism.UseFallback = true
+	return x
+}
+
+/*
+	Finish the enum, given a map from serial names to values.
+	The map must be of type `map[string]T`, where T is the type
+	the entry was built for.
+*/
+func (x *BuilderEnumMorphism) Of(members interface{}) *AtlasEntry {
+	cfg := x.entry.EnumMorphism
+	members_rv := reflect.ValueOf(members)
+	if members_rv.Kind() != reflect.Map || members_rv.Type().Key().Kind() != reflect.String || members_rv.Type().Elem() != x.entry.Type {
+		panic(fmt.Errorf("enum members for type %q must be given as a map[string]%s, not %T", x.entry.Type, x.entry.Type, members))
+	}
+	for _, name_rv := range members_rv.MapKeys() {
+		name := name_rv.String()
+		value_rv := members_rv.MapIndex(name_rv)
+		key := EnumKey(value_rv)
+		if prev, exists := cfg.Mappings[key]; exists {
+			panic(fmt.Errorf("repeated value %v in enum for type %q (named both %q and %q)", value_rv, x.entry.Type, prev, name))
+		}
+
+		cfg.Elements[name] = value_rv
+		cfg.Mappings[key] = name
+		cfg.KnownMembers = append(cfg.KnownMembers, name)
+	}
+	if _, exists := cfg.Elements[cfg.Fallback]; cfg.UseFallback && !exists {
+		panic(fmt.Errorf("fallback %q for enum type %q is not one of its members", cfg.Fallback, x.entry.Type))
+	}
+	sort.Strings(cfg.KnownMembers)
+	return x.entry
+}
+
+// Normalizes a value of an enum type to a key for EnumMorphism.Mappings.
+// Used by obj package, not meant for user facing.
+func EnumKey(rv reflect.Value) interface{} {
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint()
+	default:
+		panic(fmt.Errorf("invalid kind %s for enum", rv.Kind()))
+	}
+}
@@ -7,6 +7,24 @@ type StructMap struct {
 	// Each entry specifies the name by which each field should be referenced
 	// when serialized, and defines a way to get an address to the field.
 	Fields []StructMapEntry
+
+	// If true, the struct is serialized as an array of its field values,
+	// positionally in the order of Fields, instead of as a map.
+	// SerialNames are not emitted in this mode, and entries flagged
+	// Ignore are skipped entirely (they do not occupy a position).
+	// A run of OmitEmpty fields at the end of the Fields list is treated as
+	// optional: they are left off the array when empty during marshal,
+	// and may be absent from a shorter array during unmarshal.
+	Tuple bool
+
+	// If set, keys which match none of the Fields are collected into this
+	// field during unmarshal, instead of raising an ErrNoSuchField;
+	// and during marshal, the entries of this field are emitted after all
+	// the other fields, so documents with unknown keys can be round-tripped.
+	// The field must be a map with string keys (typically
+	// `map[string]interface{}`).  Its SerialName is unused.
+	// Not valid in combination with Tuple.
+	Extra *StructMapEntry
 }
 
 type StructMapEntry struct {
@@ -28,6 +46,32 @@ type StructMapEntry struct {
 
 	// If true, marshalling will skip this field if it's the zero value.
 	OmitEmpty bool
+
+	// If true, unmarshalling a map which doesn't contain this key is an error.
+	// (By default, absent keys simply leave the field untouched.)
+	// Has no effect for structs mapped as a Tuple.
+	Required bool
+
+	// If set, unmarshalling a map which doesn't contain this key will set
+	// the field to this value.  It must be assignable (or convertible) to Type.
+	// Beware that a default which is a map, slice, or pointer will be shared
+	// by every value it's applied to; use DefaultFunc to avoid that.
+	// Has no effect for structs mapped as a Tuple.
+	Default interface{}
+
+	// Like Default, but called to produce a fresh value each time a default
+	// is needed.  Takes precedence over Default if both are set.
+	DefaultFunc func() interface{}
+
+	// If true, marshalling will skip this field if it's equal to the default
+	// (as per reflect.DeepEqual).
+	// Has no effect if neither Default nor DefaultFunc is set.
+	OmitDefault bool
+}
+
+// HasDefault returns whether either Default or DefaultFunc is set.
+func (e StructMapEntry) HasDefault() bool {
+	return e.Default != nil || e.DefaultFunc != nil
 }
 
 type ReflectRoute []int
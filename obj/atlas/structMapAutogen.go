@@ -87,6 +87,7 @@ func exploreFields(rt reflect.Type, tagName string, sorter KeySortMode) []Struct
 						Type:         sf.Type,
 						tagged:       tagged,
 						OmitEmpty:    opts.Contains("omitempty"),
+						Required:     opts.Contains("required"),
 					})
 					if count[f.Type] > 1 {
 						// If there were multiple instances, add a second,
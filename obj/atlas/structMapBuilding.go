@@ -61,6 +61,74 @@ func (x *BuilderStructMap) IgnoreKey(serialKeyName string) *BuilderStructMap {
 	return x
 }
 
+/*
+	Designate a field which will collect any keys which match none of the
+	other fields in the mapping, instead of rejecting them during unmarshal.
+	On marshal, the collected entries are emitted after all the other fields
+	(in sorted order), so documents containing unknown keys -- for example,
+	from a newer version of a program -- can be round-tripped without loss.
+
+	The field must be a map with string keys; `map[string]interface{}` is
+	the usual choice.  If the field is also present in the mapping (e.g.
+	because Autogenerate was used), it is removed from there, regardless
+	of which order the builder methods are called in.
+
+	If the fieldName string doesn't map onto the structure type info,
+	or the field is not a map with string keys, a panic will be raised.
+*/
+func (x *BuilderStructMap) CaptureUnknownKeys(fieldName string) *BuilderStructMap {
+	fieldNameSplit := strings.Split(fieldName, ".")
+	rr, rt, err := fieldNameToReflectRoute(x.entry.Type, fieldNameSplit)
+	if err != nil {
+		panic(err)
+	}
+	if rt.Kind() != reflect.Map || rt.Key().Kind() != reflect.String {
+		panic(ErrStructureMismatch{x.entry.Type.Name(), "cannot capture unknown keys in field " + fieldName + ", which is not a map with string keys"})
+	}
+	if x.entry.StructMap.Tuple {
+		panic(ErrStructureMismatch{x.entry.Type.Name(), "cannot be a tuple and also capture unknown keys"})
+	}
+	x.entry.StructMap.Extra = &StructMapEntry{ReflectRoute: rr, Type: rt}
+	x.entry.StructMap.Fields = x.withoutExtraField(x.entry.StructMap.Fields)
+	return x
+}
+
+// Filters out any field entries which route to the same field as the
+// one designated for capturing unknown keys.
+func (x *BuilderStructMap) withoutExtraField(fields []StructMapEntry) []StructMapEntry {
+	extra := x.entry.StructMap.Extra
+	if extra == nil {
+		return fields
+	}
+	result := fields[:0]
+	for _, field := range fields {
+		if !field.Ignore && reflect.DeepEqual(field.ReflectRoute, extra.ReflectRoute) {
+			continue
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+/*
+	Configure the struct to be serialized as a tuple -- an array of values,
+	in the order of the fields in the mapping -- rather than a map.
+
+	Field SerialNames are disregarded in this mode.
+	Any OmitEmpty fields at the end of the mapping are optional: they are
+	dropped from the end of the array when empty during marshal, and
+	unmarshal accepts arrays which are missing them.
+	Unmarshal rejects arrays which are shorter than the non-optional fields,
+	or longer than the total number of fields.
+*/
+func (x *BuilderStructMap) AsTuple() *BuilderStructMap {
+	if x.entry.StructMap.Extra != nil {
+		panic(ErrStructureMismatch{x.entry.Type.Name(), "cannot be a tuple and also capture unknown keys"})
+	}
+	x.entry.StructMap.Tuple = true
+	return x
+}
+
 func fieldNameToReflectRoute(rt reflect.Type, fieldNameSplit []string) (rr ReflectRoute, _ reflect.Type, _ error) {
 	for _, fn := range fieldNameSplit {
 		rf, ok := rt.FieldByName(fn)
@@ -82,7 +150,7 @@ func fieldNameToReflectRoute(rt reflect.Type, fieldNameSplit []string) (rr Refle
 */
 func (x *BuilderStructMap) Autogenerate() *BuilderStructMap {
 	autoEntry := AutogenerateStructMapEntry(x.entry.Type)
-	x.entry.StructMap.Fields = append(x.entry.StructMap.Fields, autoEntry.StructMap.Fields...)
+	x.entry.StructMap.Fields = append(x.entry.StructMap.Fields, x.withoutExtraField(autoEntry.StructMap.Fields)...)
 	return x
 }
 
@@ -91,6 +159,6 @@ func (x *BuilderStructMap) Autogenerate() *BuilderStructMap {
 */
 func (x *BuilderStructMap) AutogenerateWithSortingScheme(sorting KeySortMode) *BuilderStructMap {
 	autoEntry := AutogenerateStructMapEntryUsingTags(x.entry.Type, "refmt", sorting)
-	x.entry.StructMap.Fields = append(x.entry.StructMap.Fields, autoEntry.StructMap.Fields...)
+	x.entry.StructMap.Fields = append(x.entry.StructMap.Fields, x.withoutExtraField(autoEntry.StructMap.Fields)...)
 	return x
 }
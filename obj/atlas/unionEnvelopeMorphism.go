@@ -0,0 +1,72 @@
+package atlas
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type UnionEnvelopeMorphism struct {
+	// The key which will hold the typehint.
+	KindKey string
+	// The key which will hold the member's value.
+	ContentKey string
+	// Mapping of typehint key strings to atlasEntry that should be delegated to.
+	Elements map[string]*AtlasEntry
+	// Mapping of rtid to string (roughly the dual of the Elements map).
+	Mappings map[uintptr]string
+	// Purely to have in readiness for error messaging.
+	KnownMembers []string
+}
+
+/*
+	Configure an interface type to be handled as an "envelope" union
+	(sometimes also known as "adjacently tagged"): the value is serialized
+	as a map with exactly two entries, one with the kindKey naming which
+	member of the union it is, and one with the contentKey holding the
+	member's value itself.
+
+	For example, with a kindKey of "kind" and a contentKey of "content",
+	one member of the union might be serialized as
+	`{"kind": "foo", "content": {"x": 1}}`.
+
+	Unlike an InlineUnion, the member's value may be any kind of thing.
+	During unmarshal, if the content arrives before the kind, the content
+	will be buffered until the kind arrives.
+*/
+func (x *BuilderCore) EnvelopeUnion(kindKey, contentKey string) *BuilderUnionEnvelopeMorphism {
+	if x.entry.Type.Kind() != reflect.Interface {
+		panic(fmt.Errorf("cannot use union morphisms for type %q, which is kind %s", x.entry.Type, x.entry.Type.Kind()))
+	}
+	if kindKey == contentKey {
+		panic(fmt.Errorf("cannot use envelope union for type %q: kind key and content key must differ (both are %q)", x.entry.Type, kindKey))
+	}
+	x.entry.UnionEnvelopeMorphism = &UnionEnvelopeMorphism{
+		KindKey:    kindKey,
+		ContentKey: contentKey,
+		Elements:   make(map[string]*AtlasEntry),
+		Mappings:   make(map[uintptr]string),
+	}
+	return &BuilderUnionEnvelopeMorphism{x.entry}
+}
+
+type BuilderUnionEnvelopeMorphism struct {
+	entry *AtlasEntry
+}
+
+func (x *BuilderUnionEnvelopeMorphism) Of(elements map[string]*AtlasEntry) *AtlasEntry {
+	cfg := x.entry.UnionEnvelopeMorphism
+	for hint, ent := range elements {
+		// FIXME: and sanity check that they can all be assigned to the interface ffs.
+		rtid := reflect.ValueOf(ent.Type).Pointer()
+		if prev, exists := cfg.Mappings[rtid]; exists {
+			panic(fmt.Errorf("repeated type %v in envelope union for %q: used for both %q and %q", ent.Type, x.entry.Type, prev, hint))
+		}
+
+		cfg.Elements[hint] = ent
+		cfg.Mappings[rtid] = hint
+		cfg.KnownMembers = append(cfg.KnownMembers, hint)
+	}
+	sort.Strings(cfg.KnownMembers)
+	return x.entry
+}
@@ -0,0 +1,70 @@
+package atlas
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type UnionInlineMorphism struct {
+	// The key which will be added to the member's map to hold its typehint.
+	DiscriminatorKey string
+	// Mapping of typehint key strings to atlasEntry that should be delegated to.
+	Elements map[string]*AtlasEntry
+	// Mapping of rtid to string (roughly the dual of the Elements map).
+	Mappings map[uintptr]string
+	// Purely to have in readiness for error messaging.
+	KnownMembers []string
+}
+
+/*
+	Configure an interface type to be handled as an "inline" union:
+	the concrete value is serialized as a map, and that map gains one
+	additional entry -- with the given discriminatorKey as its key -- whose
+	value is a string saying which member of the union the rest of the map is.
+
+	For example, with a discriminatorKey of "type", one member of the union
+	might be serialized as `{"type": "foo", "x": 1}`.
+
+	All members of an inline union must be structs using a StructMap
+	(and none of their fields may use the discriminatorKey as a name).
+	During unmarshal, if the discriminator is not the first entry in the map,
+	the entries preceding it will be buffered until it arrives, which is
+	less efficient than a KeyedUnion.
+*/
+func (x *BuilderCore) InlineUnion(discriminatorKey string) *BuilderUnionInlineMorphism {
+	if x.entry.Type.Kind() != reflect.Interface {
+		panic(fmt.Errorf("cannot use union morphisms for type %q, which is kind %s", x.entry.Type, x.entry.Type.Kind()))
+	}
+	x.entry.UnionInlineMorphism = &UnionInlineMorphism{
+		DiscriminatorKey: discriminatorKey,
+		Elements:         make(map[string]*AtlasEntry),
+		Mappings:         make(map[uintptr]string),
+	}
+	return &BuilderUnionInlineMorphism{x.entry}
+}
+
+type BuilderUnionInlineMorphism struct {
+	entry *AtlasEntry
+}
+
+func (x *BuilderUnionInlineMorphism) Of(elements map[string]*AtlasEntry) *AtlasEntry {
+	cfg := x.entry.UnionInlineMorphism
+	for hint, ent := range elements {
+		// FIXME: and sanity check that they can all be assigned to the interface ffs.
+		if ent.StructMap == nil || ent.StructMap.Tuple {
+			panic(fmt.Errorf("cannot use type %q in inline union for %q: members of inline unions must use a StructMap", ent.Type, x.entry.Type))
+		}
+		for _, field := range ent.StructMap.Fields {
+			if field.SerialName == cfg.DiscriminatorKey {
+				panic(fmt.Errorf("cannot use type %q in inline union for %q: it has a field named %q, which collides with the discriminator key", ent.Type, x.entry.Type, cfg.DiscriminatorKey))
+			}
+		}
+
+		cfg.Elements[hint] = ent
+		cfg.Mappings[reflect.ValueOf(ent.Type).Pointer()] = hint
+		cfg.KnownMembers = append(cfg.KnownMembers, hint)
+	}
+	sort.Strings(cfg.KnownMembers)
+	return x.entry
+}
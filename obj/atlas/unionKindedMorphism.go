@@ -0,0 +1,91 @@
+package atlas
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+type UnionKindedMorphism struct {
+	// Mapping of the token type which begins a value to the type of the member that should be filled.
+	Elements map[TokenType]reflect.Type
+	// Mapping of rtid to the atlasEntry for that member (roughly the dual of the Elements map).
+	// The entry is nil for members given by a sample value; they're handled like their type is anywhere else.
+	Mappings map[uintptr]*AtlasEntry
+	// Purely to have in readiness for error messaging.
+	KnownMembers []TokenType
+}
+
+/*
+	Configure an interface type to be handled as a union which picks
+	its concrete type based on the kind of the first token of the value:
+	for example, a string token might fill the interface with one type,
+	while a map open token fills it with another.
+
+	This is handy for the common "shorthand or long form" pattern in
+	configuration formats, where e.g. `"foo"` and `{"name":"foo", "opts":[...]}`
+	are both acceptable.
+
+	Each member entry is keyed by the TokenType that starts its serial form
+	(use TMapOpen for a map, TArrOpen for an array, and so on; TMapClose and
+	TArrClose are not valid).  A member may be given either as an AtlasEntry,
+	or as a sample value of its type (like `BuildEntry` takes), in which case
+	it's handled just as that type would be anywhere else -- by the atlas's
+	entry for it if there is one, or otherwise by default, based on its kind.
+	So plain string and int members need no entry of their own.  TInt and TUint are considered interchangeable
+	during unmarshal if only one of them is given; and since codecs only
+	yield TBigInt and TBigFloat for numbers too large for the other types,
+	a TBigInt member also receives TInt and TUint, and a TBigFloat member
+	also receives TFloat64, if no member is given for those.
+	When marshalling, no additional information is emitted at all: the
+	member's own serial form is already unambiguous.
+*/
+func (x *BuilderCore) KindedUnion() *BuilderUnionKindedMorphism {
+	if x.entry.Type.Kind() != reflect.Interface {
+		panic(fmt.Errorf("cannot use union morphisms for type %q, which is kind %s", x.entry.Type, x.entry.Type.Kind()))
+	}
+	x.entry.UnionKindedMorphism = &UnionKindedMorphism{
+		Elements: make(map[TokenType]reflect.Type),
+		Mappings: make(map[uintptr]*AtlasEntry),
+	}
+	return &BuilderUnionKindedMorphism{x.entry}
+}
+
+type BuilderUnionKindedMorphism struct {
+	entry *AtlasEntry
+}
+
+func (x *BuilderUnionKindedMorphism) Of(elements map[TokenType]interface{}) *AtlasEntry {
+	cfg := x.entry.UnionKindedMorphism
+	for kind, elem := range elements {
+		// FIXME: and sanity check that they can all be assigned to the interface ffs.
+		switch kind {
+		case TMapOpen, TArrOpen, TNull, TString, TBytes, TBool, TInt, TUint, TFloat64, TBigInt, TBigFloat:
+			// pass
+		default:
+			panic(fmt.Errorf("cannot use token type %q to select a member of kinded union for %q", kind, x.entry.Type))
+		}
+		ent, _ := elem.(*AtlasEntry)
+		var rt reflect.Type
+		if ent != nil {
+			rt = ent.Type
+		} else {
+			rt = reflect.TypeOf(elem)
+			if rt == nil || rt.Kind() == reflect.Ptr {
+				panic(fmt.Errorf("invalid member for kind %q of kinded union for %q: use an atlas entry, or a bare (non-pointer, non-nil) sample value", kind, x.entry.Type))
+			}
+		}
+		rtid := reflect.ValueOf(rt).Pointer()
+		if _, exists := cfg.Mappings[rtid]; exists {
+			panic(fmt.Errorf("repeated type %v in kinded union for %q: each type may only be used for one kind", rt, x.entry.Type))
+		}
+
+		cfg.Elements[kind] = rt
+		cfg.Mappings[rtid] = ent
+		cfg.KnownMembers = append(cfg.KnownMembers, kind)
+	}
+	sort.Slice(cfg.KnownMembers, func(i, j int) bool { return cfg.KnownMembers[i] < cfg.KnownMembers[j] })
+	return x.entry
+}
@@ -0,0 +1,64 @@
+package atlas
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type UnionTaggedMorphism struct {
+	// Mapping of tag ints to atlasEntry that should be delegated to.
+	Elements map[int]*AtlasEntry
+	// Mapping of rtid to tag (roughly the dual of the Elements map).
+	Mappings map[uintptr]int
+	// Purely to have in readiness for error messaging.
+	KnownTags []int
+}
+
+/*
+	Configure an interface type to be handled as a union which picks
+	its concrete type based on the (CBOR) tag on the value.
+
+	Each of the member entries given to `Of` must have a tag configured
+	(via `UseTag`), and the tags must be unique within the union.
+	When marshalling, the member's tag is emitted on the first token of
+	the value; when unmarshalling, the tag on the first token is used to
+	select which member type to fill.
+
+	This is only useful with serial formats that support tags (e.g. CBOR);
+	formats like JSON will drop the tags, and unmarshalling the result
+	will then fail.
+*/
+func (x *BuilderCore) TaggedUnion() *BuilderUnionTaggedMorphism {
+	if x.entry.Type.Kind() != reflect.Interface {
+		panic(fmt.Errorf("cannot use union morphisms for type %q, which is kind %s", x.entry.Type, x.entry.Type.Kind()))
+	}
+	x.entry.UnionTaggedMorphism = &UnionTaggedMorphism{
+		Elements: make(map[int]*AtlasEntry),
+		Mappings: make(map[uintptr]int),
+	}
+	return &BuilderUnionTaggedMorphism{x.entry}
+}
+
+type BuilderUnionTaggedMorphism struct {
+	entry *AtlasEntry
+}
+
+func (x *BuilderUnionTaggedMorphism) Of(elements ...*AtlasEntry) *AtlasEntry {
+	cfg := x.entry.UnionTaggedMorphism
+	for _, ent := range elements {
+		// FIXME: and sanity check that they can all be assigned to the interface ffs.
+		if !ent.Tagged {
+			panic(fmt.Errorf("cannot use type %q in tagged union for %q: it has no tag configured", ent.Type, x.entry.Type))
+		}
+		if prev, exists := cfg.Elements[ent.Tag]; exists {
+			panic(fmt.Errorf("repeated tag %v on type %v in tagged union for %q (already mapped to type %v)", ent.Tag, ent.Type, x.entry.Type, prev.Type))
+		}
+
+		cfg.Elements[ent.Tag] = ent
+		cfg.Mappings[reflect.ValueOf(ent.Type).Pointer()] = ent.Tag
+		cfg.KnownTags = append(cfg.KnownTags, ent.Tag)
+	}
+	sort.Ints(cfg.KnownTags)
+	return x.entry
+}
@@ -1,7 +1,10 @@
 package obj
 
 import (
+	"math/big"
 	. "reflect"
+
+	"github.com/polydawn/refmt/tok"
 )
 
 var (
@@ -21,4 +24,11 @@ var (
 	rtid_uintptr = ValueOf(TypeOf(uintptr(0))).Pointer()
 	rtid_float32 = ValueOf(TypeOf(float32(0))).Pointer()
 	rtid_float64 = ValueOf(TypeOf(float64(0))).Pointer()
+	rtid_raw     = ValueOf(TypeOf(Raw{})).Pointer()
+)
+
+var (
+	rtid_bigInt   = ValueOf(TypeOf(big.Int{})).Pointer()
+	rtid_bigFloat = ValueOf(TypeOf(big.Float{})).Pointer()
+	rtid_number   = ValueOf(TypeOf(tok.Number(""))).Pointer()
 )
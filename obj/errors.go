@@ -10,6 +10,23 @@ import (
 // General note: avoid using reflect.Type here.  It doesn't do well with `go-cmp`,
 //  which in turn makes our tests for error paths a lot jankier.
 
+// ErrAtPath wraps any error from marshalling or unmarshalling which happened
+// somewhere inside the value, rather than at its root, with the path to
+// where it happened -- for example `.spec.containers[3].ports["http"]`.
+// Struct fields are named by their serial names; map keys and array indexes in brackets.
+type ErrAtPath struct {
+	Path string
+	Err  error
+}
+
+func (e ErrAtPath) Error() string {
+	return fmt.Sprintf("at %s: %s", e.Path, e.Err)
+}
+
+func (e ErrAtPath) Unwrap() error {
+	return e.Err
+}
+
 // ErrInvalidUnmarshalTarget describes an invalid argument passed to Unmarshaller.Bind.
 // (Unmarshalling must target a non-nil pointer so that it can address the value.)
 type ErrInvalidUnmarshalTarget struct {
@@ -68,6 +85,46 @@ func (e ErrNoSuchField) Error() string {
 	return fmt.Sprintf("unmarshal error: stream contains key %q, but there's no such field in structs of type %s", e.Name, e.Type)
 }
 
+// ErrRepeatedKey is the error returned when unmarshalling into a union in
+// envelope format and the token stream for the map contains one of its
+// keys more than once.
+type ErrRepeatedKey struct {
+	Name string // Key name from the token.
+	Type string // Type name of the value we're operating on.
+}
+
+func (e ErrRepeatedKey) Error() string {
+	return fmt.Sprintf("unmarshal error: stream contains key %q more than once, for value of type %s", e.Name, e.Type)
+}
+
+// ErrMissingField is the error returned when unmarshalling into a struct and
+// the token stream for the map lacks keys for fields which are marked as required.
+type ErrMissingField struct {
+	Names []string // Serial names of all the required fields which were absent.
+	Type  string   // Type name of the struct we're operating on.
+}
+
+func (e ErrMissingField) Error() string {
+	return fmt.Sprintf("unmarshal error: stream is missing required keys %q for struct of type %s", e.Names, e.Type)
+}
+
+// ErrTupleLength is the error returned when unmarshalling into a struct
+// which is mapped as a tuple, and the array in the token stream has
+// fewer entries than the struct requires, or more than it has fields for.
+type ErrTupleLength struct {
+	Type   string // Type name of the struct we're operating on.
+	Len    int    // Number of entries in the stream.  (If too many, this may be counted only up to the first excess entry.)
+	MinLen int    // Number of entries required.
+	MaxLen int    // Number of entries permitted.
+}
+
+func (e ErrTupleLength) Error() string {
+	if e.MinLen == e.MaxLen {
+		return fmt.Sprintf("unmarshal error: tuple for struct of type %s must have exactly %d entries, but stream contains %d", e.Type, e.MaxLen, e.Len)
+	}
+	return fmt.Sprintf("unmarshal error: tuple for struct of type %s must have between %d and %d entries, but stream contains %d", e.Type, e.MinLen, e.MaxLen, e.Len)
+}
+
 // ErrNoSuchUnionMember is the error returned when unmarshalling into a union
 // interface and the token stream contains a key which does not name any of the
 // known members of the union.
@@ -80,3 +137,85 @@ type ErrNoSuchUnionMember struct {
 func (e ErrNoSuchUnionMember) Error() string {
 	return fmt.Sprintf("unmarshal error: cannot unmarshal into union %s: %q is not one of the known members (expected one of %s)", e.Type, e.Name, e.KnownMembers)
 }
+
+// ErrMissingUnionDiscriminator is the error returned when unmarshalling into a
+// union interface and the token stream for the map ends without ever containing
+// the key which says which member of the union the value is.
+type ErrMissingUnionDiscriminator struct {
+	Key          string   // The discriminator key we were looking for.
+	Type         string   // Type name of the interface we're trying to fill.
+	KnownMembers []string // Members we could have accepted.
+}
+
+func (e ErrMissingUnionDiscriminator) Error() string {
+	return fmt.Sprintf("unmarshal error: cannot unmarshal into union %s: map has no %q entry naming a member (expected one of %s)", e.Type, e.Key, e.KnownMembers)
+}
+
+// ErrMissingUnionContent is the error returned when unmarshalling into a
+// union interface in envelope format, and the token stream for the map ends
+// without ever containing the key which holds the member's value.
+type ErrMissingUnionContent struct {
+	Key  string // The content key we were looking for.
+	Type string // Type name of the interface we're trying to fill.
+}
+
+func (e ErrMissingUnionContent) Error() string {
+	return fmt.Sprintf("unmarshal error: cannot unmarshal into union %s: map has no %q entry holding the member's value", e.Type, e.Key)
+}
+
+// ErrNoSuchUnionTag is the error returned when unmarshalling into a union
+// interface which is demuxed by tag, and the token stream contains a value
+// with no tag, or with a tag that does not correspond to any of the known
+// members of the union.
+type ErrNoSuchUnionTag struct {
+	Tagged    bool   // False if the value in the stream had no tag at all.
+	Tag       int    // Tag from the token.
+	Type      string // Type name of the interface we're trying to fill.
+	KnownTags []int  // Tags we expected instead.
+}
+
+func (e ErrNoSuchUnionTag) Error() string {
+	if !e.Tagged {
+		return fmt.Sprintf("unmarshal error: cannot unmarshal into union %s: value has no tag (expected one of %v)", e.Type, e.KnownTags)
+	}
+	return fmt.Sprintf("unmarshal error: cannot unmarshal into union %s: tag %d is not one of the known members (expected one of %v)", e.Type, e.Tag, e.KnownTags)
+}
+
+// ErrNoSuchUnionKind is the error returned when unmarshalling into a union
+// interface which is demuxed by the kind of token, and the token stream
+// contains a kind of value that isn't used by any of the known members of the union.
+type ErrNoSuchUnionKind struct {
+	Got          TokenType   // Token in the stream that started the value.
+	Type         string      // Type name of the interface we're trying to fill.
+	KnownMembers []TokenType // Kinds we expected instead.
+}
+
+func (e ErrNoSuchUnionKind) Error() string {
+	return fmt.Sprintf("unmarshal error: cannot unmarshal into union %s: %s is not one of the known members (expected one of %s)", e.Type, e.Got, e.KnownMembers)
+}
+
+// ErrNoSuchEnumMember is the error returned when unmarshalling into an enum
+// type and the token stream contains a string which does not name any of the
+// known members of the enum.
+type ErrNoSuchEnumMember struct {
+	Name         string   // Name from the token.
+	Type         string   // Type name of the enum we're trying to fill.
+	KnownMembers []string // Members we expected instead.
+}
+
+func (e ErrNoSuchEnumMember) Error() string {
+	return fmt.Sprintf("unmarshal error: cannot unmarshal into enum %s: %q is not one of the known members (expected one of %s)", e.Type, e.Name, e.KnownMembers)
+}
+
+// ErrNoSuchEnumValue is the error returned when marshalling an enum type
+// whose value is not one of the known members of the enum, and the enum
+// has no fallback.
+type ErrNoSuchEnumValue struct {
+	Value        string   // The value, formatted with %v.
+	Type         string   // Type name of the enum we're trying to marshal.
+	KnownMembers []string // Names of the members we could have marshalled.
+}
+
+func (e ErrNoSuchEnumValue) Error() string {
+	return fmt.Sprintf("marshal error: cannot marshal enum %s: value %s is not one of the known members (expected one of %s)", e.Type, e.Value, e.KnownMembers)
+}
@@ -61,7 +61,7 @@ func (d *Marshaller) Step(tok *Token) (bool, error) {
 	//	fmt.Printf(">> yield is %#v\n", TokenToString(*tok))
 	// If the step errored: out, entirely.
 	if err != nil {
-		return true, err
+		return true, d.errAtPath(err)
 	}
 	// If the step wasn't done, return same status.
 	if !done {
@@ -78,6 +78,22 @@ func (d *Marshaller) Step(tok *Token) (bool, error) {
 	return false, nil
 }
 
+// Wraps an error with the path to the value being marshalled when it occurred.
+// See Unmarshaller.errAtPath; this works the same way.
+func (d *Marshaller) errAtPath(err error) error {
+	if _, ok := err.(ErrAtPath); ok {
+		return err
+	}
+	var path []byte
+	for _, mach := range d.stack {
+		path = appendPathStep(mach, path)
+	}
+	if len(path) == 0 {
+		return err
+	}
+	return ErrAtPath{string(path), err}
+}
+
 /*
 	Starts the process of recursing marshalling over value `rv`.
 
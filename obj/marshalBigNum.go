@@ -0,0 +1,52 @@
+package obj
+
+import (
+	"math/big"
+	"reflect"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Emits a big.Int as a TBigInt token, regardless of its magnitude;
+// encoders pick the smallest form their format has for it.
+type marshalMachineBigInt struct {
+	rv reflect.Value
+}
+
+func (mach *marshalMachineBigInt) Reset(_ *marshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	return nil
+}
+
+func (mach *marshalMachineBigInt) Step(_ *Marshaller, _ *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TBigInt
+	if mach.rv.CanAddr() {
+		tok.BigInt = mach.rv.Addr().Interface().(*big.Int)
+	} else {
+		v := mach.rv.Interface().(big.Int)
+		tok.BigInt = &v
+	}
+	return true, nil
+}
+
+// Emits a big.Float as a TBigFloat token, regardless of its magnitude;
+// encoders pick the smallest form their format has for it.
+type marshalMachineBigFloat struct {
+	rv reflect.Value
+}
+
+func (mach *marshalMachineBigFloat) Reset(_ *marshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	return nil
+}
+
+func (mach *marshalMachineBigFloat) Step(_ *Marshaller, _ *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TBigFloat
+	if mach.rv.CanAddr() {
+		tok.BigFloat = mach.rv.Addr().Interface().(*big.Float)
+	} else {
+		v := mach.rv.Interface().(big.Float)
+		tok.BigFloat = &v
+	}
+	return true, nil
+}
@@ -90,3 +90,7 @@ func (mach *marshalMachinePrimitive) Step(_ *Marshaller, _ *marshalSlab, tok *To
 		panic("unhandled")
 	}
 }
+
+func (mach *ptrDerefDelegateMarshalMachine) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.MarshalMachine, path)
+}
@@ -0,0 +1,38 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type marshalMachineEnum struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv reflect.Value
+}
+
+func (mach *marshalMachineEnum) Reset(_ *marshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.target_rv = rv
+	return nil
+}
+
+func (mach *marshalMachineEnum) Step(_ *Marshaller, _ *marshalSlab, tok *Token) (done bool, err error) {
+	cfg := mach.cfg.EnumMorphism
+	name, ok := cfg.Mappings[atlas.EnumKey(mach.target_rv)]
+	if !ok {
+		if !cfg.UseFallback {
+			return true, ErrNoSuchEnumValue{fmt.Sprint(mach.target_rv), mach.cfg.Type.String(), cfg.KnownMembers}
+		}
+		name = cfg.Fallback
+	}
+	tok.Type = TString
+	tok.Str = name
+	if mach.cfg.Tagged {
+		tok.Tagged = true
+		tok.Tag = mach.cfg.Tag
+	}
+	return true, nil
+}
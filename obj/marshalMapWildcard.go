@@ -146,3 +146,7 @@ func (x wildcardMapStringyKey_RFC7049) Less(i, j int) bool {
 	}
 	return li < lj
 }
+
+func (mach *marshalMachineMapWildcard) appendPathStep(path []byte) []byte {
+	return appendPathKey(path, mach.keys[mach.index-1].s)
+}
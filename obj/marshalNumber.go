@@ -0,0 +1,28 @@
+package obj
+
+import (
+	"reflect"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Emits a Number as a TNumber token, so its text is kept exactly
+// by encoders which can do so.
+type marshalMachineNumber struct {
+	rv reflect.Value
+}
+
+func (mach *marshalMachineNumber) Reset(_ *marshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	return nil
+}
+
+func (mach *marshalMachineNumber) Step(_ *Marshaller, _ *marshalSlab, tok *Token) (done bool, err error) {
+	n := Number(mach.rv.String())
+	if !n.IsValid() {
+		return true, ErrInvalidNumber{string(n)}
+	}
+	tok.Type = TNumber
+	tok.Str = string(n)
+	return true, nil
+}
@@ -0,0 +1,29 @@
+package obj
+
+import (
+	"reflect"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Replays the tokens in a Raw verbatim.
+type marshalMachineRaw struct {
+	raw Raw
+	i   int
+}
+
+func (mach *marshalMachineRaw) Reset(_ *marshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.raw = rv.Interface().(Raw)
+	mach.i = 0
+	return mach.raw.validate()
+}
+
+func (mach *marshalMachineRaw) Step(_ *Marshaller, _ *marshalSlab, tok *Token) (done bool, err error) {
+	if len(mach.raw) == 0 {
+		tok.Type = TNull
+		return true, nil
+	}
+	*tok = mach.raw[mach.i]
+	mach.i++
+	return mach.i == len(mach.raw), nil
+}
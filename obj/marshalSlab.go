@@ -25,8 +25,18 @@ type marshalSlabRow struct {
 	marshalMachineMapWildcard
 	marshalMachineSliceWildcard
 	marshalMachineStructAtlas
+	marshalMachineStructTupleAtlas
 	marshalMachineTransform
 	marshalMachineUnionKeyed
+	marshalMachineUnionInline
+	marshalMachineUnionTagged
+	marshalMachineUnionEnvelope
+	marshalMachineUnionKinded
+	marshalMachineEnum
+	marshalMachineRaw
+	marshalMachineBigInt
+	marshalMachineBigFloat
+	marshalMachineNumber
 
 	errThunkMarshalMachine
 }
@@ -109,6 +119,14 @@ func _yieldBareMarshalMachinePtr(row *marshalSlabRow, atl atlas.Atlas, rt reflec
 		rtid_bytes:
 		row.marshalMachinePrimitive.kind = rt.Kind()
 		return &row.marshalMachinePrimitive
+	case rtid_raw:
+		return &row.marshalMachineRaw
+	case rtid_bigInt:
+		return &row.marshalMachineBigInt
+	case rtid_bigFloat:
+		return &row.marshalMachineBigFloat
+	case rtid_number:
+		return &row.marshalMachineNumber
 	}
 
 	// Consult atlas second.
@@ -177,12 +195,30 @@ func _yieldMarshalMachinePtrForAtlasEntry(row *marshalSlabRow, entry *atlas.Atla
 		row.marshalMachineTransform.tagged = entry.Tagged
 		row.marshalMachineTransform.tag = entry.Tag
 		return &row.marshalMachineTransform
+	case entry.StructMap != nil && entry.StructMap.Tuple:
+		row.marshalMachineStructTupleAtlas.cfg = entry
+		return &row.marshalMachineStructTupleAtlas
 	case entry.StructMap != nil:
 		row.marshalMachineStructAtlas.cfg = entry
 		return &row.marshalMachineStructAtlas
 	case entry.UnionKeyedMorphism != nil:
 		row.marshalMachineUnionKeyed.cfg = entry
 		return &row.marshalMachineUnionKeyed
+	case entry.UnionInlineMorphism != nil:
+		row.marshalMachineUnionInline.cfg = entry
+		return &row.marshalMachineUnionInline
+	case entry.UnionTaggedMorphism != nil:
+		row.marshalMachineUnionTagged.cfg = entry
+		return &row.marshalMachineUnionTagged
+	case entry.UnionEnvelopeMorphism != nil:
+		row.marshalMachineUnionEnvelope.cfg = entry
+		return &row.marshalMachineUnionEnvelope
+	case entry.UnionKindedMorphism != nil:
+		row.marshalMachineUnionKinded.cfg = entry
+		return &row.marshalMachineUnionKinded
+	case entry.EnumMorphism != nil:
+		row.marshalMachineEnum.cfg = entry
+		return &row.marshalMachineEnum
 	case entry.MapMorphism != nil:
 		row.marshalMachineMapWildcard.morphism = entry.MapMorphism
 		return &row.marshalMachineMapWildcard
@@ -3,6 +3,7 @@ package obj
 import (
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/polydawn/refmt/obj/atlas"
 	. "github.com/polydawn/refmt/tok"
@@ -14,16 +15,50 @@ type marshalMachineStructAtlas struct {
 	target_rv reflect.Value
 	index     int           // Progress marker
 	value_rv  reflect.Value // Next value (or nil if next step is key).
+
+	extra_rv   reflect.Value // The map of captured unknown keys, if the StructMap has an Extra field.
+	extraKeys  []string      // Sorted keys of extra_rv; emitted after all the fields.
+	extraIndex int           // Progress marker within extraKeys.
 }
 
 func (mach *marshalMachineStructAtlas) Reset(slab *marshalSlab, rv reflect.Value, _ reflect.Type) error {
 	mach.target_rv = rv
 	mach.index = -1
 	mach.value_rv = reflect.Value{}
+	mach.extraKeys = mach.extraKeys[:0]
+	mach.extraIndex = 0
+	if extra := mach.cfg.StructMap.Extra; extra != nil {
+		mach.extra_rv = extra.ReflectRoute.TraverseToValue(rv)
+		if err := mach.loadExtraKeys(); err != nil {
+			return err
+		}
+	}
 	slab.grow() // we'll reuse the same row for all fields
 	return nil
 }
 
+// Gathers and sorts the keys in the extra field, rejecting any which
+// would collide with the keys of regular fields.
+func (mach *marshalMachineStructAtlas) loadExtraKeys() error {
+	if !mach.extra_rv.IsValid() || mach.extra_rv.Len() == 0 {
+		return nil
+	}
+	for _, k_rv := range mach.extra_rv.MapKeys() {
+		mach.extraKeys = append(mach.extraKeys, k_rv.String())
+	}
+	sort.Strings(mach.extraKeys)
+	for _, fieldEntry := range mach.cfg.StructMap.Fields {
+		if fieldEntry.Ignore {
+			continue
+		}
+		i := sort.SearchStrings(mach.extraKeys, fieldEntry.SerialName)
+		if i < len(mach.extraKeys) && mach.extraKeys[i] == fieldEntry.SerialName {
+			return fmt.Errorf("cannot marshal %s: captured unknown key %q collides with a field", mach.cfg.Type, fieldEntry.SerialName)
+		}
+	}
+	return nil
+}
+
 func (mach *marshalMachineStructAtlas) Step(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
 	//fmt.Printf("--step on %#v: i=%d/%d v=%v\n", mach.target_rv, mach.index, len(mach.cfg.Fields), mach.value)
 
@@ -31,13 +66,16 @@ func (mach *marshalMachineStructAtlas) Step(driver *Marshaller, slab *marshalSla
 	nEntries := len(mach.cfg.StructMap.Fields)
 	if mach.index < 0 {
 		tok.Type = TMapOpen
-		tok.Length = countEmittableStructFields(mach.cfg, mach.target_rv)
+		tok.Length = countEmittableStructFields(mach.cfg, mach.target_rv) + len(mach.extraKeys)
 		tok.Tagged = mach.cfg.Tagged
 		tok.Tag = mach.cfg.Tag
 		mach.index++
 		return false, nil
 	}
 	if mach.index == nEntries {
+		if mach.extraIndex < len(mach.extraKeys) {
+			return mach.step_extra(driver, slab, tok)
+		}
 		tok.Type = TMapClose
 		mach.index++
 		slab.release()
@@ -67,15 +105,16 @@ func (mach *marshalMachineStructAtlas) Step(driver *Marshaller, slab *marshalSla
 	for fieldEntry.Ignore {
 		mach.index++
 		if mach.index == nEntries {
-			tok.Type = TMapClose
-			mach.index++
-			slab.release()
-			return true, nil
+			return mach.Step(driver, slab, tok)
 		}
 		fieldEntry = mach.cfg.StructMap.Fields[mach.index]
 	}
 	mach.value_rv = fieldEntry.ReflectRoute.TraverseToValue(mach.target_rv)
-	if fieldEntry.OmitEmpty && isEmptyValue(mach.value_rv) {
+	omit, err := isOmittableStructField(fieldEntry, mach.value_rv)
+	if err != nil {
+		return true, err
+	}
+	if omit {
 		mach.value_rv = reflect.Value{}
 		mach.index++
 		return mach.Step(driver, slab, tok)
@@ -85,8 +124,30 @@ func (mach *marshalMachineStructAtlas) Step(driver *Marshaller, slab *marshalSla
 	return false, nil
 }
 
+// Emits the captured unknown keys and their values, after all the regular fields.
+func (mach *marshalMachineStructAtlas) step_extra(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	if mach.value_rv != (reflect.Value{}) {
+		child_rv := mach.value_rv
+		child_rt := mach.extra_rv.Type().Elem()
+		mach.extraIndex++
+		mach.value_rv = reflect.Value{}
+		return false, driver.Recurse(
+			tok,
+			child_rv,
+			child_rt,
+			slab.yieldMachine(child_rt),
+		)
+	}
+	key := mach.extraKeys[mach.extraIndex]
+	mach.value_rv = mach.extra_rv.MapIndex(reflect.ValueOf(key).Convert(mach.extra_rv.Type().Key()))
+	tok.Type = TString
+	tok.Str = key
+	return false, nil
+}
+
 // Count how many fields in a struct should actually be marshalled.
-// Fields that are tagged omitEmpty and are isEmptyValue are not counted, and
+// Fields that are tagged omitEmpty and are isEmptyValue are not counted
+// (nor those tagged omitDefault and equal to their default), and
 // StructMapEntry used to flag ignored fields unmarshalling never count, so
 // this number may be less than the number of fields in the AtlasEntry.StructMap.
 func countEmittableStructFields(cfg *atlas.AtlasEntry, target_rv reflect.Value) int {
@@ -95,14 +156,33 @@ func countEmittableStructFields(cfg *atlas.AtlasEntry, target_rv reflect.Value)
 		if fieldEntry.Ignore {
 			continue
 		}
-		if !fieldEntry.OmitEmpty {
+		// Errors are ignored here; they'll be raised when we reach the field.
+		if omit, _ := isOmittableStructField(fieldEntry, fieldEntry.ReflectRoute.TraverseToValue(target_rv)); !omit {
 			total++
-			continue
-		}
-		if !isEmptyValue(fieldEntry.ReflectRoute.TraverseToValue(target_rv)) {
-			total++
-			continue
 		}
 	}
 	return total
 }
+
+// Check if a field should be skipped because it's tagged omitEmpty and is empty,
+// or tagged omitDefault and is equal to its default.
+func isOmittableStructField(fieldEntry atlas.StructMapEntry, field_rv reflect.Value) (bool, error) {
+	if fieldEntry.OmitEmpty && isEmptyValue(field_rv) {
+		return true, nil
+	}
+	if fieldEntry.OmitDefault && fieldEntry.HasDefault() && field_rv.IsValid() {
+		def_rv, err := structFieldDefault(fieldEntry)
+		if err != nil {
+			return false, err
+		}
+		return reflect.DeepEqual(field_rv.Interface(), def_rv.Interface()), nil
+	}
+	return false, nil
+}
+
+func (mach *marshalMachineStructAtlas) appendPathStep(path []byte) []byte {
+	if mach.extraIndex > 0 {
+		return appendPathField(path, mach.extraKeys[mach.extraIndex-1])
+	}
+	return appendPathField(path, mach.cfg.StructMap.Fields[mach.index-1].SerialName)
+}
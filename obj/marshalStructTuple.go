@@ -0,0 +1,94 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type marshalMachineStructTupleAtlas struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv reflect.Value
+	index     int // Progress marker
+	end       int // Index in fields to stop at (trailing empty optional fields are trimmed).
+	length    int // Number of entries we'll emit.
+}
+
+func (mach *marshalMachineStructTupleAtlas) Reset(slab *marshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.target_rv = rv
+	mach.index = -1
+	mach.end, mach.length = countEmittableTupleFields(mach.cfg, rv)
+	slab.grow() // we'll reuse the same row for all fields
+	return nil
+}
+
+func (mach *marshalMachineStructTupleAtlas) Step(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	// Check boundaries and do the special steps or either start or end.
+	if mach.index < 0 {
+		tok.Type = TArrOpen
+		tok.Length = mach.length
+		tok.Tagged = mach.cfg.Tagged
+		tok.Tag = mach.cfg.Tag
+		mach.index++
+		return false, nil
+	}
+	if mach.index > mach.end {
+		return true, fmt.Errorf("invalid state: entire struct (%d fields) already consumed", mach.end)
+	}
+	for mach.index < mach.end && mach.cfg.StructMap.Fields[mach.index].Ignore {
+		mach.index++
+	}
+	if mach.index == mach.end {
+		tok.Type = TArrClose
+		mach.index++
+		slab.release()
+		return true, nil
+	}
+
+	// Recurse into the next field.  (No keys to yield here; position is everything.)
+	fieldEntry := mach.cfg.StructMap.Fields[mach.index]
+	mach.index++
+	return false, driver.Recurse(
+		tok,
+		fieldEntry.ReflectRoute.TraverseToValue(mach.target_rv),
+		fieldEntry.Type,
+		slab.yieldMachine(fieldEntry.Type),
+	)
+}
+
+// Figure out how much of a tuple-style struct should actually be marshalled.
+// Returns the index in the StructMap.Fields to stop before, and the number of
+// entries that will be emitted before reaching it.
+// Trailing fields that are tagged omitEmpty and are isEmptyValue are trimmed;
+// omitEmpty fields that are followed by other emitted fields still take their position.
+func countEmittableTupleFields(cfg *atlas.AtlasEntry, target_rv reflect.Value) (end int, length int) {
+	for i, fieldEntry := range cfg.StructMap.Fields {
+		if fieldEntry.Ignore {
+			continue
+		}
+		if fieldEntry.OmitEmpty && isEmptyValue(fieldEntry.ReflectRoute.TraverseToValue(target_rv)) {
+			continue
+		}
+		end = i + 1
+	}
+	for _, fieldEntry := range cfg.StructMap.Fields[:end] {
+		if !fieldEntry.Ignore {
+			length++
+		}
+	}
+	return
+}
+
+func (mach *marshalMachineStructTupleAtlas) appendPathStep(path []byte) []byte {
+	// Ignored fields don't take up a position in the array.
+	pos := 0
+	for _, fieldEntry := range mach.cfg.StructMap.Fields[:mach.index-1] {
+		if !fieldEntry.Ignore {
+			pos++
+		}
+	}
+	return appendPathIndex(path, pos)
+}
@@ -33,3 +33,7 @@ func (mach *marshalMachineTransform) Step(driver *Marshaller, slab *marshalSlab,
 	}
 	return
 }
+
+func (mach *marshalMachineTransform) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
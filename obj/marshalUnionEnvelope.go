@@ -0,0 +1,89 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type marshalMachineUnionEnvelope struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv   reflect.Value // the element (interface already unwrapped).
+	elementName string        // the serial name for this union member type.
+
+	step     marshalMachineStep
+	delegate MarshalMachine // actual machine, picked based on content of the interface.
+}
+
+func (mach *marshalMachineUnionEnvelope) Reset(slab *marshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv.Elem()
+	if mach.target_rv.Kind() == reflect.Invalid {
+		return fmt.Errorf("nil is not a valid member for the union for interface %q", mach.cfg.Type.Name())
+	}
+	element_rt := mach.target_rv.Type()
+	mach.elementName = mach.cfg.UnionEnvelopeMorphism.Mappings[reflect.ValueOf(element_rt).Pointer()]
+	if mach.elementName == "" {
+		return fmt.Errorf("type %q is not one of the known members of the union for interface %q", element_rt.Name(), mach.cfg.Type.Name())
+	}
+	delegateAtlasEnt := mach.cfg.UnionEnvelopeMorphism.Elements[mach.elementName]
+	mach.delegate = _yieldMarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	if err := mach.delegate.Reset(slab, mach.target_rv, delegateAtlasEnt.Type); err != nil {
+		return err
+	}
+	mach.step = mach.step_emitMapOpen
+	return nil
+}
+
+func (mach *marshalMachineUnionEnvelope) Step(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	return mach.step(driver, slab, tok)
+}
+
+func (mach *marshalMachineUnionEnvelope) step_emitMapOpen(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TMapOpen
+	tok.Length = 2
+	mach.step = mach.step_emitKindKey
+	return false, nil
+}
+
+func (mach *marshalMachineUnionEnvelope) step_emitKindKey(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TString
+	tok.Str = mach.cfg.UnionEnvelopeMorphism.KindKey
+	mach.step = mach.step_emitKind
+	return false, nil
+}
+
+func (mach *marshalMachineUnionEnvelope) step_emitKind(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TString
+	tok.Str = mach.elementName
+	mach.step = mach.step_emitContentKey
+	return false, nil
+}
+
+func (mach *marshalMachineUnionEnvelope) step_emitContentKey(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TString
+	tok.Str = mach.cfg.UnionEnvelopeMorphism.ContentKey
+	mach.step = mach.step_delegate
+	return false, nil
+}
+
+func (mach *marshalMachineUnionEnvelope) step_delegate(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	done, err = mach.delegate.Step(driver, slab, tok)
+	if done && err == nil {
+		mach.step = mach.step_emitMapClose
+		return false, nil
+	}
+	return
+}
+
+func (mach *marshalMachineUnionEnvelope) step_emitMapClose(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TMapClose
+	mach.step = nil
+	return true, nil
+}
+
+func (mach *marshalMachineUnionEnvelope) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -0,0 +1,82 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type marshalMachineUnionInline struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv   reflect.Value // the element (interface already unwrapped).
+	elementName string        // the serial name for this union member type.
+
+	step     marshalMachineStep
+	delegate MarshalMachine // actual machine, picked based on content of the interface.
+}
+
+func (mach *marshalMachineUnionInline) Reset(slab *marshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv.Elem()
+	if mach.target_rv.Kind() == reflect.Invalid {
+		return fmt.Errorf("nil is not a valid member for the union for interface %q", mach.cfg.Type.Name())
+	}
+	element_rt := mach.target_rv.Type()
+	mach.elementName = mach.cfg.UnionInlineMorphism.Mappings[reflect.ValueOf(element_rt).Pointer()]
+	if mach.elementName == "" {
+		return fmt.Errorf("type %q is not one of the known members of the union for interface %q", element_rt.Name(), mach.cfg.Type.Name())
+	}
+	delegateAtlasEnt := mach.cfg.UnionInlineMorphism.Elements[mach.elementName]
+	mach.delegate = _yieldMarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	if err := mach.delegate.Reset(slab, mach.target_rv, delegateAtlasEnt.Type); err != nil {
+		return err
+	}
+	mach.step = mach.step_emitMapOpen
+	return nil
+}
+
+func (mach *marshalMachineUnionInline) Step(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	return mach.step(driver, slab, tok)
+}
+
+func (mach *marshalMachineUnionInline) step_emitMapOpen(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	// Let the delegate emit its own map open, so it can tell us its length.
+	//  We'll widen that by one, for the discriminator entry we're going to inject.
+	done, err = mach.delegate.Step(driver, slab, tok)
+	if err != nil {
+		return true, err
+	}
+	if done || tok.Type != TMapOpen {
+		return true, fmt.Errorf("type %q cannot be a member of inline union for interface %q: it did not serialize as a map", mach.target_rv.Type().Name(), mach.cfg.Type.Name())
+	}
+	if tok.Length >= 0 {
+		tok.Length++
+	}
+	mach.step = mach.step_emitKey
+	return false, nil
+}
+
+func (mach *marshalMachineUnionInline) step_emitKey(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TString
+	tok.Str = mach.cfg.UnionInlineMorphism.DiscriminatorKey
+	mach.step = mach.step_emitDiscriminator
+	return false, nil
+}
+
+func (mach *marshalMachineUnionInline) step_emitDiscriminator(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	tok.Type = TString
+	tok.Str = mach.elementName
+	mach.step = mach.step_delegate
+	return false, nil
+}
+
+func (mach *marshalMachineUnionInline) step_delegate(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	// The delegate's map close is our map close, so its done is our done.
+	return mach.delegate.Step(driver, slab, tok)
+}
+
+func (mach *marshalMachineUnionInline) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -69,3 +69,7 @@ func (mach *marshalMachineUnionKeyed) step_emitMapClose(driver *Marshaller, slab
 	mach.step = nil
 	return true, nil
 }
+
+func (mach *marshalMachineUnionKeyed) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -0,0 +1,44 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type marshalMachineUnionKinded struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv reflect.Value  // the element (interface already unwrapped).
+	delegate  MarshalMachine // actual machine, picked based on content of the interface.
+}
+
+func (mach *marshalMachineUnionKinded) Reset(slab *marshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv.Elem()
+	if mach.target_rv.Kind() == reflect.Invalid {
+		return fmt.Errorf("nil is not a valid member for the union for interface %q", mach.cfg.Type.Name())
+	}
+	element_rt := mach.target_rv.Type()
+	delegateAtlasEnt, ok := mach.cfg.UnionKindedMorphism.Mappings[reflect.ValueOf(element_rt).Pointer()]
+	if !ok {
+		return fmt.Errorf("type %q is not one of the known members of the union for interface %q", element_rt.Name(), mach.cfg.Type.Name())
+	}
+	// Members given without an entry get whatever machine their type would anywhere.
+	if delegateAtlasEnt != nil {
+		mach.delegate = _yieldMarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	} else {
+		mach.delegate = _yieldMarshalMachinePtr(slab.tip(), slab.atlas, element_rt)
+	}
+	return mach.delegate.Reset(slab, mach.target_rv, element_rt)
+}
+
+func (mach *marshalMachineUnionKinded) Step(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	// The member's serial form is already unambiguous; nothing to add.
+	return mach.delegate.Step(driver, slab, tok)
+}
+
+func (mach *marshalMachineUnionKinded) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -0,0 +1,58 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type marshalMachineUnionTagged struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv reflect.Value // the element (interface already unwrapped).
+	tag       int           // the tag for this union member type.
+
+	step     marshalMachineStep
+	delegate MarshalMachine // actual machine, picked based on content of the interface.
+}
+
+func (mach *marshalMachineUnionTagged) Reset(slab *marshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv.Elem()
+	if mach.target_rv.Kind() == reflect.Invalid {
+		return fmt.Errorf("nil is not a valid member for the union for interface %q", mach.cfg.Type.Name())
+	}
+	element_rt := mach.target_rv.Type()
+	var ok bool
+	mach.tag, ok = mach.cfg.UnionTaggedMorphism.Mappings[reflect.ValueOf(element_rt).Pointer()]
+	if !ok {
+		return fmt.Errorf("type %q is not one of the known members of the union for interface %q", element_rt.Name(), mach.cfg.Type.Name())
+	}
+	delegateAtlasEnt := mach.cfg.UnionTaggedMorphism.Elements[mach.tag]
+	mach.delegate = _yieldMarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	if err := mach.delegate.Reset(slab, mach.target_rv, delegateAtlasEnt.Type); err != nil {
+		return err
+	}
+	mach.step = mach.step_emitTagged
+	return nil
+}
+
+func (mach *marshalMachineUnionTagged) Step(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	return mach.step(driver, slab, tok)
+}
+
+func (mach *marshalMachineUnionTagged) step_emitTagged(driver *Marshaller, slab *marshalSlab, tok *Token) (done bool, err error) {
+	// The delegate will usually have glued the tag on already (since the
+	//  member entries are required to have one), but set it regardless:
+	//  the tag is the only thing that makes the union readable again.
+	done, err = mach.delegate.Step(driver, slab, tok)
+	tok.Tagged = true
+	tok.Tag = mach.tag
+	mach.step = mach.delegate.Step
+	return
+}
+
+func (mach *marshalMachineUnionTagged) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -35,3 +35,7 @@ func (mach marshalMachineWildcard) Step(driver *Marshaller, slab *marshalSlab, t
 	}
 	return mach.delegate.Step(driver, slab, tok)
 }
+
+func (mach *marshalMachineWildcard) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
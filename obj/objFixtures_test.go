@@ -565,7 +565,7 @@ var objFixtures = []struct {
 				expectErr: ErrInvalidUnmarshalTarget{reflect.TypeOf([]int{})}},
 			{title: "into *[]int",
 				slotFn:    func() interface{} { var v []int; return &v },
-				expectErr: ErrUnmarshalTypeCantFit{Token{Type: TString, Str: "value"}, reflect.ValueOf(0), 0}},
+				expectErr: ErrAtPath{"[0]", ErrUnmarshalTypeCantFit{Token{Type: TString, Str: "value"}, reflect.ValueOf(0), 0}}},
 		},
 	},
 	{title: "maps in maps",
@@ -1494,7 +1494,7 @@ func TestUnmarshaller(t *testing.T) {
 					maybe(fmt.Sprintf("targetting %s (%s|%T):", trr.title, slotKind, slot), func() {
 
 						// Set up unmarshaller.
-						unmarshaller := NewUnmarshaller(tr.atlas)
+						unmarshaller := NewUnmarshaller(tr.atlas, UnmarshalOptions{})
 						err := unmarshaller.Bind(slot)
 						if err != nil && trr.expectErr != nil {
 							Convey("Result (error expected)", func() {
@@ -0,0 +1,135 @@
+package obj
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+// The usual deep equality can't see into big numbers,
+// so these tests drive the machines directly and compare values by their string forms.
+func TestBigNumHandling(t *testing.T) {
+	type tLedger struct {
+		Amount  *big.Int
+		Balance big.Int
+		Rate    *big.Float
+	}
+	atl := atlas.MustBuild(
+		atlas.BuildEntry(tLedger{}).StructMap().Autogenerate().Complete(),
+	)
+	huge, _ := new(big.Int).SetString("1180591620717411303424", 10) // 2^70
+	t.Run("unmarshal struct with big fields", func(t *testing.T) {
+		var slot tLedger
+		err := bigNumUnmarshal(atl, &slot, []Token{
+			{Type: TMapOpen, Length: 3},
+			TokStr("amount"), {Type: TBigInt, BigInt: huge},
+			TokStr("balance"), {Type: TUint, Uint: 5},
+			TokStr("rate"), {Type: TFloat64, Float64: 1.5},
+			{Type: TMapClose},
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, slot.Amount.String(), ShouldEqual, "1180591620717411303424")
+		Wish(t, slot.Balance.String(), ShouldEqual, "5")
+		Wish(t, slot.Rate.String(), ShouldEqual, "1.5")
+	})
+	t.Run("unmarshal null into big pointers", func(t *testing.T) {
+		slot := tLedger{Amount: big.NewInt(1), Rate: big.NewFloat(1)}
+		err := bigNumUnmarshal(atl, &slot, []Token{
+			{Type: TMapOpen, Length: 2},
+			TokStr("amount"), {Type: TNull},
+			TokStr("rate"), {Type: TNull},
+			{Type: TMapClose},
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, slot.Amount == nil, ShouldEqual, true)
+		Wish(t, slot.Rate == nil, ShouldEqual, true)
+	})
+	t.Run("marshal struct with big fields", func(t *testing.T) {
+		value := tLedger{Amount: huge, Rate: big.NewFloat(1.5)}
+		value.Balance.SetInt64(5)
+		seq, err := bigNumMarshal(atl, value)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, fmt.Sprint(seq), ShouldEqual, "[<{:3> <s:\"amount\"> <I:1180591620717411303424> <s:\"balance\"> <I:5> <s:\"rate\"> <F:1.5> <}>]")
+	})
+	t.Run("marshal nil big pointers", func(t *testing.T) {
+		seq, err := bigNumMarshal(atl, tLedger{})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, fmt.Sprint(seq), ShouldEqual, "[<{:3> <s:\"amount\"> <0> <s:\"balance\"> <I:0> <s:\"rate\"> <0> <}>]")
+	})
+	t.Run("unmarshal bigint into int", func(t *testing.T) {
+		t.Run("fits", func(t *testing.T) {
+			var slot int64
+			err := bigNumUnmarshal(atl, &slot, []Token{{Type: TBigInt, BigInt: big.NewInt(-7)}})
+			Wish(t, err, ShouldEqual, nil)
+			Wish(t, slot, ShouldEqual, int64(-7))
+		})
+		t.Run("too big", func(t *testing.T) {
+			var slot uint64
+			tok := Token{Type: TBigInt, BigInt: huge}
+			err := bigNumUnmarshal(atl, &slot, []Token{tok})
+			Wish(t, err, ShouldEqual, ErrUnmarshalTypeCantFit{tok, reflect.ValueOf(&slot).Elem(), 0})
+		})
+	})
+	t.Run("unmarshal into wildcard", func(t *testing.T) {
+		t.Run("bigint", func(t *testing.T) {
+			var slot interface{}
+			err := bigNumUnmarshal(atl, &slot, []Token{{Type: TBigInt, BigInt: huge}})
+			Wish(t, err, ShouldEqual, nil)
+			Wish(t, fmt.Sprintf("%T %v", slot, slot), ShouldEqual, "*big.Int 1180591620717411303424")
+		})
+		t.Run("bigfloat", func(t *testing.T) {
+			var slot interface{}
+			err := bigNumUnmarshal(atl, &slot, []Token{{Type: TBigFloat, BigFloat: big.NewFloat(0.25)}})
+			Wish(t, err, ShouldEqual, nil)
+			Wish(t, fmt.Sprintf("%T %v", slot, slot), ShouldEqual, "*big.Float 0.25")
+		})
+		t.Run("uint too big for int", func(t *testing.T) {
+			var slot interface{}
+			err := bigNumUnmarshal(atl, &slot, []Token{{Type: TUint, Uint: 1 << 63}})
+			Wish(t, err, ShouldEqual, nil)
+			Wish(t, slot, ShouldEqual, uint64(1<<63))
+		})
+	})
+}
+
+func bigNumUnmarshal(atl atlas.Atlas, slot interface{}, sequence []Token) error {
+	unmarshaller := NewUnmarshaller(atl, UnmarshalOptions{})
+	if err := unmarshaller.Bind(slot); err != nil {
+		return err
+	}
+	for i := range sequence {
+		done, err := unmarshaller.Step(&sequence[i])
+		if err != nil {
+			return err
+		}
+		if done != (i == len(sequence)-1) {
+			return fmt.Errorf("unmarshaller finished at step %d of %d", i+1, len(sequence))
+		}
+	}
+	return nil
+}
+
+func bigNumMarshal(atl atlas.Atlas, value interface{}) ([]Token, error) {
+	marshaller := NewMarshaller(atl)
+	if err := marshaller.Bind(value); err != nil {
+		return nil, err
+	}
+	var seq []Token
+	for {
+		var tok Token
+		done, err := marshaller.Step(&tok)
+		if err != nil {
+			return seq, err
+		}
+		seq = append(seq, tok)
+		if done {
+			return seq, nil
+		}
+	}
+}
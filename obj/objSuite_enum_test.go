@@ -0,0 +1,118 @@
+package obj
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type tColor int
+
+const (
+	tColorUnknown tColor = iota
+	tColorRed
+	tColorGreen
+)
+
+type tFruit string
+
+func TestEnumHandling(t *testing.T) {
+	t.Run("int enum", func(t *testing.T) {
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tColor(0)).Enum().Of(map[string]tColor{
+				"red":   tColorRed,
+				"green": tColorGreen,
+			}),
+		)
+		t.Run("marshal", func(t *testing.T) {
+			value := tColorGreen
+			checkMarshalling(t, atl, value, []Token{TokStr("green")}, nil)
+		})
+		t.Run("marshal unknown value", func(t *testing.T) {
+			value := tColor(12)
+			checkMarshalling(t, atl, value, []Token{{}}, ErrNoSuchEnumValue{"12", "obj.tColor", []string{"green", "red"}})
+		})
+		t.Run("unmarshal", func(t *testing.T) {
+			slot := tColor(0)
+			expect := tColorGreen
+			checkUnmarshalling(t, atl, &slot, []Token{TokStr("green")}, &expect, nil)
+		})
+		t.Run("unmarshal unknown name", func(t *testing.T) {
+			slot := tColor(0)
+			expect := tColor(0)
+			checkUnmarshalling(t, atl, &slot, []Token{TokStr("blue")}, &expect, ErrNoSuchEnumMember{"blue", "obj.tColor", []string{"green", "red"}})
+		})
+		t.Run("unmarshal rejects int", func(t *testing.T) {
+			slot := tColor(0)
+			expect := tColor(0)
+			checkUnmarshalling(t, atl, &slot, []Token{TokInt(1)}, &expect, ErrUnmarshalTypeCantFit{TokInt(1), reflect.ValueOf(&slot).Elem(), 0})
+		})
+	})
+	t.Run("int enum with fallback", func(t *testing.T) {
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tColor(0)).Enum().Fallback("unknown").Of(map[string]tColor{
+				"unknown": tColorUnknown,
+				"red":     tColorRed,
+				"green":   tColorGreen,
+			}),
+		)
+		t.Run("marshal unknown value", func(t *testing.T) {
+			value := tColor(12)
+			checkMarshalling(t, atl, value, []Token{TokStr("unknown")}, nil)
+		})
+		t.Run("unmarshal unknown name", func(t *testing.T) {
+			slot := tColorRed
+			expect := tColorUnknown
+			checkUnmarshalling(t, atl, &slot, []Token{TokStr("blue")}, &expect, nil)
+		})
+	})
+	t.Run("string enum", func(t *testing.T) {
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tFruit("")).Enum().Of(map[string]tFruit{
+				"apple":  "apple",
+				"banana": "Banana",
+			}),
+		)
+		t.Run("marshal", func(t *testing.T) {
+			checkMarshalling(t, atl, tFruit("apple"), []Token{TokStr("apple")}, nil)
+			checkMarshalling(t, atl, tFruit("Banana"), []Token{TokStr("banana")}, nil)
+		})
+		t.Run("unmarshal", func(t *testing.T) {
+			slot := tFruit("")
+			expect := tFruit("Banana")
+			checkUnmarshalling(t, atl, &slot, []Token{TokStr("banana")}, &expect, nil)
+		})
+		t.Run("unmarshal unknown name", func(t *testing.T) {
+			slot := tFruit("")
+			expect := tFruit("")
+			checkUnmarshalling(t, atl, &slot, []Token{TokStr("Banana")}, &expect, ErrNoSuchEnumMember{"Banana", "obj.tFruit", []string{"apple", "banana"}})
+		})
+	})
+	t.Run("enum in struct", func(t *testing.T) {
+		type tPaint struct {
+			Color tColor
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tPaint{}).StructMap().Autogenerate().Complete(),
+			atlas.BuildEntry(tColor(0)).Enum().Of(map[string]tColor{
+				"red":   tColorRed,
+				"green": tColorGreen,
+			}),
+		)
+		seq := []Token{
+			{Type: TMapOpen, Length: 1},
+			TokStr("color"), TokStr("red"),
+			{Type: TMapClose},
+		}
+		t.Run("marshal", func(t *testing.T) {
+			checkMarshalling(t, atl, tPaint{tColorRed}, seq, nil)
+		})
+		t.Run("unmarshal", func(t *testing.T) {
+			slot := &tPaint{}
+			expect := &tPaint{tColorRed}
+			checkUnmarshalling(t, atl, slot, seq, expect, nil)
+		})
+	})
+}
@@ -0,0 +1,73 @@
+package obj
+
+import (
+	"testing"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+func TestNumberHandling(t *testing.T) {
+	type tPrice struct {
+		Amount Number
+		Count  int
+		Ratio  float64
+	}
+	atl := atlas.MustBuild(
+		atlas.BuildEntry(tPrice{}).StructMap().Autogenerate().Complete(),
+	)
+	t.Run("numbers as text into struct fields", func(t *testing.T) {
+		seq := []Token{
+			{Type: TMapOpen, Length: 3},
+			TokStr("amount"), {Type: TNumber, Str: "10.50"},
+			TokStr("count"), {Type: TNumber, Str: "3"},
+			TokStr("ratio"), {Type: TNumber, Str: "1e-3"},
+			{Type: TMapClose},
+		}
+		t.Run("unmarshal", func(t *testing.T) {
+			checkUnmarshalling(t, atl, &tPrice{}, seq, &tPrice{"10.50", 3, 0.001}, nil)
+		})
+	})
+	t.Run("Number field from other numeric tokens", func(t *testing.T) {
+		for _, tr := range []struct {
+			tok    Token
+			expect Number
+		}{
+			{Token{Type: TInt, Int: -4}, "-4"},
+			{Token{Type: TUint, Uint: 18446744073709551615}, "18446744073709551615"},
+			{Token{Type: TFloat64, Float64: 0.1}, "0.1"},
+		} {
+			t.Run(tr.tok.String(), func(t *testing.T) {
+				var slot Number
+				expect := tr.expect
+				checkUnmarshalling(t, atl, &slot, []Token{tr.tok}, &expect, nil)
+			})
+		}
+	})
+	t.Run("Number field marshals as text", func(t *testing.T) {
+		checkMarshalling(t, atl, tPrice{"10.50", 3, 0.5}, []Token{
+			{Type: TMapOpen, Length: 3},
+			TokStr("amount"), {Type: TNumber, Str: "10.50"},
+			TokStr("count"), {Type: TInt, Int: 3},
+			TokStr("ratio"), {Type: TFloat64, Float64: 0.5},
+			{Type: TMapClose},
+		}, nil)
+	})
+	t.Run("invalid Number won't marshal", func(t *testing.T) {
+		checkMarshalling(t, atl, Number("1,000"), []Token{{}}, ErrInvalidNumber{"1,000"})
+	})
+	t.Run("wildcard keeps numbers as text", func(t *testing.T) {
+		var slot interface{}
+		var expect interface{} = map[string]interface{}{"a": Number("1.0")}
+		checkUnmarshalling(t, atl, &slot, []Token{
+			{Type: TMapOpen, Length: 1},
+			TokStr("a"), {Type: TNumber, Str: "1.0"},
+			{Type: TMapClose},
+		}, &expect, nil)
+	})
+	t.Run("invalid number text into int", func(t *testing.T) {
+		var slot int
+		expect := 0
+		checkUnmarshalling(t, atl, &slot, []Token{{Type: TNumber, Str: "1,000"}}, &expect, ErrInvalidNumber{"1,000"})
+	})
+}
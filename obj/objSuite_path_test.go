@@ -0,0 +1,103 @@
+package obj
+
+import (
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+func TestErrorPaths(t *testing.T) {
+	type tContainer struct {
+		Ports map[string]int
+	}
+	type tSpec struct {
+		Containers []tContainer
+	}
+	type tDoc struct {
+		Spec tSpec
+	}
+	atl := atlas.MustBuild(
+		atlas.BuildEntry(tContainer{}).StructMap().Autogenerate().Complete(),
+		atlas.BuildEntry(tSpec{}).StructMap().Autogenerate().Complete(),
+		atlas.BuildEntry(tDoc{}).StructMap().Autogenerate().Complete(),
+	)
+	docTokens := func(secondPort Token) []Token {
+		return []Token{
+			{Type: TMapOpen, Length: 1}, TokStr("spec"),
+			{Type: TMapOpen, Length: 1}, TokStr("containers"),
+			{Type: TArrOpen, Length: 2},
+			{Type: TMapOpen, Length: 1}, TokStr("ports"),
+			{Type: TMapOpen, Length: 1}, TokStr("http"), TokInt(80), {Type: TMapClose},
+			{Type: TMapClose},
+			{Type: TMapOpen, Length: 1}, TokStr("ports"),
+			{Type: TMapOpen, Length: 1}, TokStr("http"), secondPort, {Type: TMapClose},
+			{Type: TMapClose},
+			{Type: TArrClose},
+			{Type: TMapClose},
+			{Type: TMapClose},
+		}
+	}
+	unmarshalErr := func(slot interface{}, seq []Token) error {
+		unmarshaller := NewUnmarshaller(atl, UnmarshalOptions{})
+		if err := unmarshaller.Bind(slot); err != nil {
+			return err
+		}
+		for _, tok := range seq {
+			if _, err := unmarshaller.Step(&tok); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("unmarshal error deep in the tree", func(t *testing.T) {
+		err := unmarshalErr(&tDoc{}, docTokens(TokStr("eighty")))
+		pathErr, ok := err.(ErrAtPath)
+		Wish(t, ok, ShouldEqual, true)
+		Wish(t, pathErr.Path, ShouldEqual, `.spec.containers[1].ports["http"]`)
+		_, ok = pathErr.Err.(ErrUnmarshalTypeCantFit)
+		Wish(t, ok, ShouldEqual, true)
+		Wish(t, err.Error(), ShouldEqual, `at .spec.containers[1].ports["http"]: unmarshal error: cannot assign <s:"eighty"> to int field`)
+	})
+	t.Run("unmarshal error about a key names the map it's in", func(t *testing.T) {
+		seq := docTokens(TokInt(8080))
+		seq[13] = TokStr("bogus")
+		err := unmarshalErr(&tDoc{}, seq)
+		Wish(t, err, ShouldEqual, ErrAtPath{`.spec.containers[1]`, ErrNoSuchField{"bogus", "obj.tContainer"}})
+	})
+	t.Run("unmarshal error at the root is not wrapped", func(t *testing.T) {
+		err := unmarshalErr(&tDoc{}, []Token{{Type: TMapOpen, Length: 1}, TokStr("bogus")})
+		Wish(t, err, ShouldEqual, ErrNoSuchField{"bogus", "obj.tDoc"})
+	})
+	t.Run("successful unmarshal is unaffected", func(t *testing.T) {
+		slot := &tDoc{}
+		Wish(t, unmarshalErr(slot, docTokens(TokInt(8080))), ShouldEqual, nil)
+		Wish(t, slot.Spec.Containers[1].Ports["http"], ShouldEqual, 8080)
+	})
+	t.Run("marshal error deep in the tree", func(t *testing.T) {
+		type tBadPorts struct {
+			Ports map[int]string
+		}
+		type tBadDoc struct {
+			Containers []tBadPorts
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tBadPorts{}).StructMap().Autogenerate().Complete(),
+			atlas.BuildEntry(tBadDoc{}).StructMap().Autogenerate().Complete(),
+		)
+		marshaller := NewMarshaller(atl)
+		Wish(t, marshaller.Bind(tBadDoc{[]tBadPorts{{}, {map[int]string{1: "x"}}}}), ShouldEqual, nil)
+		var tok Token
+		var err error
+		for n := 0; n < 20 && err == nil; n++ {
+			_, err = marshaller.Step(&tok)
+		}
+		pathErr, ok := err.(ErrAtPath)
+		Wish(t, ok, ShouldEqual, true)
+		Wish(t, pathErr.Path, ShouldEqual, `.containers[0].ports`)
+		Wish(t, pathErr.Err.Error(), ShouldEqual, `unsupported map key type "int"`)
+	})
+}
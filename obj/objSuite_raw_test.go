@@ -0,0 +1,74 @@
+package obj
+
+import (
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func TestRawHandling(t *testing.T) {
+	type tMsg struct {
+		Kind    string
+		Payload Raw
+	}
+	type tPayload struct {
+		S string
+		M map[string]interface{}
+		I int
+		K *string
+	}
+	atl := atlas.MustBuild(
+		atlas.BuildEntry(tMsg{}).StructMap().Autogenerate().Complete(),
+		atlas.BuildEntry(tPayload{}).StructMap().Autogenerate().Complete(),
+	)
+	payloadSeq := fixtures.SequenceMap["jumbles nested in map"].Tokens
+	seq := append([]Token{
+		{Type: TMapOpen, Length: 2},
+		TokStr("kind"), TokStr("jumble"),
+		TokStr("payload"),
+	}, payloadSeq...)
+	seq = append(seq, Token{Type: TMapClose})
+	captured := tMsg{"jumble", Raw(payloadSeq)}
+
+	t.Run("unmarshal captures tokens", func(t *testing.T) {
+		slot := &tMsg{}
+		checkUnmarshalling(t, atl, slot, seq, &captured, nil)
+	})
+	t.Run("unmarshal captures scalars", func(t *testing.T) {
+		slot := &Raw{}
+		checkUnmarshalling(t, atl, slot, []Token{TokStr("x")}, &Raw{TokStr("x")}, nil)
+	})
+	t.Run("marshal replays tokens", func(t *testing.T) {
+		checkMarshalling(t, atl, captured, seq, nil)
+		checkMarshalling(t, atl, &captured, seq, nil)
+	})
+	t.Run("marshal empty raw as null", func(t *testing.T) {
+		checkMarshalling(t, atl, Raw(nil), []Token{{Type: TNull}}, nil)
+	})
+	t.Run("unmarshal raw later", func(t *testing.T) {
+		var payload tPayload
+		err := captured.Payload.Unmarshal(atl, &payload)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, payload, ShouldEqual, tPayload{"foo", map[string]interface{}{}, 42, nil})
+	})
+	t.Run("unmarshal empty raw as null", func(t *testing.T) {
+		payload := &tPayload{S: "foo"}
+		err := Raw(nil).Unmarshal(atl, &payload)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, payload, ShouldEqual, (*tPayload)(nil))
+
+		var tok Token
+		done, err := Raw{}.TokenSource().Step(&tok)
+		Wish(t, done, ShouldEqual, true)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, tok, ShouldEqual, Token{Type: TNull})
+	})
+	t.Run("marshal rejects incomplete raw", func(t *testing.T) {
+		err := NewMarshaller(atl).Bind(Raw(payloadSeq[:3]))
+		Wish(t, err == nil, ShouldEqual, false)
+	})
+}
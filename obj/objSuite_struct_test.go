@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
 	"github.com/polydawn/refmt/tok/fixtures"
 )
 
@@ -86,6 +87,14 @@ func TestStructHandling(t *testing.T) {
 				seq := seq[:2]
 				checkUnmarshalling(t, atlas, slot, seq, expect, ErrNoSuchField{"key", reflect.TypeOf(tObjStr{}).String()})
 			})
+			t.Run("with ignore unknown fields option", func(t *testing.T) {
+				atlas := atlas.MustBuild(
+					atlas.BuildEntry(tObjStr{}).StructMap().Autogenerate().Complete(),
+				)
+				slot := &tObjStr{"untouched"}
+				expect := &tObjStr{"untouched"}
+				checkUnmarshallingWithOptions(t, atlas, UnmarshalOptions{IgnoreUnknownFields: true}, slot, seq, expect, nil)
+			})
 			t.Run("with keyignore configured", func(t *testing.T) {
 				atlas := atlas.MustBuild(
 					atlas.BuildEntry(tObjStr{}).StructMap().
@@ -221,4 +230,164 @@ func TestStructHandling(t *testing.T) {
 			})
 		})
 	})
+	t.Run("tokens for map with unknown keys", func(t *testing.T) {
+		seq := fixtures.SequenceMap["jumbles nested in map"].Tokens
+		type tObj struct {
+			S     string
+			Extra map[string]interface{}
+		}
+		atlas := atlas.MustBuild(
+			atlas.BuildEntry(tObj{}).StructMap().
+				Autogenerate().
+				CaptureUnknownKeys("Extra").
+				Complete(),
+		)
+		captured := tObj{"foo", map[string]interface{}{
+			"m": map[string]interface{}{},
+			"i": 42,
+			"k": nil,
+		}}
+		t.Run("unmarshal captures", func(t *testing.T) {
+			slot := &tObj{}
+			checkUnmarshalling(t, atlas, slot, seq, &captured, nil)
+		})
+		t.Run("unmarshal overwriting replaces captured keys", func(t *testing.T) {
+			slot := &tObj{"bar", map[string]interface{}{"stale": true}}
+			checkUnmarshalling(t, atlas, slot, seq, &captured, nil)
+		})
+		t.Run("unmarshal with no unknown keys", func(t *testing.T) {
+			seq := fixtures.SequenceMap["single row map"].Tokens.Clone()
+			seq[1].Str = "s"
+			slot := &tObj{"bar", map[string]interface{}{"stale": true}}
+			checkUnmarshalling(t, atlas, slot, seq, &tObj{"value", nil}, nil)
+		})
+		t.Run("marshal re-emits after fields in sorted order", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 4},
+				TokStr("s"), TokStr("foo"),
+				TokStr("i"), TokInt(42),
+				TokStr("k"), {Type: TNull},
+				TokStr("m"), {Type: TMapOpen, Length: 0}, {Type: TMapClose},
+				{Type: TMapClose},
+			}
+			checkMarshalling(t, atlas, captured, seq, nil)
+			checkMarshalling(t, atlas, &captured, seq, nil)
+		})
+		t.Run("capture takes precedence over ignore unknown fields option", func(t *testing.T) {
+			slot := &tObj{}
+			checkUnmarshallingWithOptions(t, atlas, UnmarshalOptions{IgnoreUnknownFields: true}, slot, seq, &captured, nil)
+		})
+		t.Run("marshal with nothing captured", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 1},
+				TokStr("s"), TokStr("foo"),
+				{Type: TMapClose},
+			}
+			checkMarshalling(t, atlas, tObj{"foo", nil}, seq, nil)
+		})
+	})
+	t.Run("tokens for map with deep unknown entries", func(t *testing.T) {
+		seq := []Token{
+			{Type: TMapOpen, Length: 3},
+			TokStr("a"), {Type: TArrOpen, Length: 2},
+			{Type: TMapOpen, Length: 1}, TokStr("s"), TokStr("x"), {Type: TMapClose},
+			{Type: TArrOpen, Length: 0}, {Type: TArrClose},
+			{Type: TArrClose},
+			TokStr("s"), TokStr("foo"),
+			TokStr("b"), {Type: TMapOpen, Length: 1},
+			TokStr("s"), {Type: TMapOpen, Length: -1}, {Type: TMapClose},
+			{Type: TMapClose},
+			{Type: TMapClose},
+		}
+		type tObj struct {
+			S string
+		}
+		atlas := atlas.MustBuild(
+			atlas.BuildEntry(tObj{}).StructMap().Autogenerate().Complete(),
+		)
+		t.Run("unmarshal rejected by default", func(t *testing.T) {
+			slot := &tObj{}
+			checkUnmarshalling(t, atlas, slot, seq[:2], &tObj{}, ErrNoSuchField{"a", reflect.TypeOf(tObj{}).String()})
+		})
+		t.Run("unmarshal skips entire subtrees with ignore unknown fields option", func(t *testing.T) {
+			slot := &tObj{}
+			checkUnmarshallingWithOptions(t, atlas, UnmarshalOptions{IgnoreUnknownFields: true}, slot, seq, &tObj{"foo"}, nil)
+		})
+	})
+	t.Run("required fields", func(t *testing.T) {
+		seq := fixtures.SequenceMap["single row map"].Tokens
+		t.Run("with explicit atlas", func(t *testing.T) {
+			type tObj struct {
+				X string
+				Y string
+				Z string
+			}
+			atlas := atlas.MustBuild(
+				atlas.BuildEntry(tObj{}).StructMap().
+					AddField("X", atlas.StructMapEntry{SerialName: "key", Required: true}).
+					AddField("Y", atlas.StructMapEntry{SerialName: "y", Required: true}).
+					AddField("Z", atlas.StructMapEntry{SerialName: "z", Required: true}).
+					Complete(),
+			)
+			t.Run("unmarshal reports all absent keys", func(t *testing.T) {
+				slot := &tObj{}
+				expect := &tObj{X: "value"}
+				checkUnmarshalling(t, atlas, slot, seq, expect, ErrMissingField{[]string{"y", "z"}, reflect.TypeOf(tObj{}).String()})
+			})
+		})
+		t.Run("with autogen tag", func(t *testing.T) {
+			type tObj struct {
+				Key   string `refmt:",required"`
+				Spare string
+			}
+			atlas := atlas.MustBuild(
+				atlas.BuildEntry(tObj{}).StructMap().Autogenerate().Complete(),
+			)
+			t.Run("unmarshal accepted when present", func(t *testing.T) {
+				slot := &tObj{}
+				expect := &tObj{Key: "value"}
+				checkUnmarshalling(t, atlas, slot, seq, expect, nil)
+			})
+			t.Run("unmarshal rejected when absent", func(t *testing.T) {
+				seq := fixtures.SequenceMap["empty map"].Tokens
+				slot := &tObj{}
+				expect := &tObj{}
+				checkUnmarshalling(t, atlas, slot, seq, expect, ErrMissingField{[]string{"key"}, reflect.TypeOf(tObj{}).String()})
+			})
+		})
+	})
+	t.Run("default values", func(t *testing.T) {
+		seq := fixtures.SequenceMap["single row map"].Tokens
+		type tObj struct {
+			X    string
+			Port uint16
+			Tags []string
+		}
+		atlas := atlas.MustBuild(
+			atlas.BuildEntry(tObj{}).StructMap().
+				AddField("X", atlas.StructMapEntry{SerialName: "key", Default: "dflt", OmitDefault: true}).
+				AddField("Port", atlas.StructMapEntry{SerialName: "port", Default: 8080, OmitDefault: true}).
+				AddField("Tags", atlas.StructMapEntry{SerialName: "tags", DefaultFunc: func() interface{} { return []string{"a"} }}).
+				Complete(),
+		)
+		t.Run("unmarshal fills absent fields", func(t *testing.T) {
+			slot := &tObj{}
+			expect := &tObj{"value", 8080, []string{"a"}}
+			checkUnmarshalling(t, atlas, slot, seq, expect, nil)
+		})
+		t.Run("unmarshal overwriting fills absent fields", func(t *testing.T) {
+			slot := &tObj{"should be overruled", 1, []string{"b"}}
+			expect := &tObj{"value", 8080, []string{"a"}}
+			checkUnmarshalling(t, atlas, slot, seq, expect, nil)
+		})
+		t.Run("marshal omits fields equal to default", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2},
+				TokStr("port"), {Type: TUint, Uint: 80},
+				TokStr("tags"), {Type: TArrOpen, Length: 1}, TokStr("a"), {Type: TArrClose},
+				{Type: TMapClose},
+			}
+			checkMarshalling(t, atlas, tObj{"dflt", 80, []string{"a"}}, seq, nil)
+		})
+	})
 }
@@ -11,7 +11,12 @@ import (
 
 func checkUnmarshalling(t *testing.T, atl atlas.Atlas, slot interface{}, sequence []tok.Token, expect interface{}, expectErr error) {
 	t.Helper()
-	unmarshaller := NewUnmarshaller(atl)
+	checkUnmarshallingWithOptions(t, atl, UnmarshalOptions{}, slot, sequence, expect, expectErr)
+}
+
+func checkUnmarshallingWithOptions(t *testing.T, atl atlas.Atlas, cfg UnmarshalOptions, slot interface{}, sequence []tok.Token, expect interface{}, expectErr error) {
+	t.Helper()
+	unmarshaller := NewUnmarshaller(atl, cfg)
 	err := unmarshaller.Bind(slot)
 	Wish(t, err, ShouldEqual, nil)
 
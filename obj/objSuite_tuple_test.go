@@ -0,0 +1,203 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+func TestTupleHandling(t *testing.T) {
+	t.Run("tuple with all fields required", func(t *testing.T) {
+		type tTuple struct {
+			A string
+			B int
+			C string
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tTuple{}).StructMap().Autogenerate().AsTuple().Complete(),
+		)
+		seq := []Token{
+			{Type: TArrOpen, Length: 3},
+			TokStr("a"),
+			TokInt(2),
+			TokStr("c"),
+			{Type: TArrClose},
+		}
+		t.Run("marshal", func(t *testing.T) {
+			value := tTuple{"a", 2, "c"}
+			checkMarshalling(t, atl, value, seq, nil)
+			checkMarshalling(t, atl, &value, seq, nil)
+		})
+		t.Run("unmarshal", func(t *testing.T) {
+			slot := &tTuple{}
+			expect := &tTuple{"a", 2, "c"}
+			checkUnmarshalling(t, atl, slot, seq, expect, nil)
+		})
+		t.Run("unmarshal with unknown length", func(t *testing.T) {
+			seq := append([]Token{{Type: TArrOpen, Length: -1}}, seq[1:]...)
+			slot := &tTuple{}
+			expect := &tTuple{"a", 2, "c"}
+			checkUnmarshalling(t, atl, slot, seq, expect, nil)
+		})
+		t.Run("unmarshal rejects declared short length", func(t *testing.T) {
+			seq := []Token{{Type: TArrOpen, Length: 2}}
+			slot := &tTuple{}
+			expect := &tTuple{}
+			checkUnmarshalling(t, atl, slot, seq, expect, ErrTupleLength{reflect.TypeOf(tTuple{}).String(), 2, 3, 3})
+		})
+		t.Run("unmarshal rejects short tuple", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: -1},
+				TokStr("a"),
+				TokInt(2),
+				{Type: TArrClose},
+			}
+			slot := &tTuple{}
+			expect := &tTuple{"a", 2, ""}
+			checkUnmarshalling(t, atl, slot, seq, expect, ErrTupleLength{reflect.TypeOf(tTuple{}).String(), 2, 3, 3})
+		})
+		t.Run("unmarshal rejects long tuple", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: -1},
+				TokStr("a"),
+				TokInt(2),
+				TokStr("c"),
+				TokStr("d"),
+			}
+			slot := &tTuple{}
+			expect := &tTuple{"a", 2, "c"}
+			checkUnmarshalling(t, atl, slot, seq, expect, ErrTupleLength{reflect.TypeOf(tTuple{}).String(), 4, 3, 3})
+		})
+		t.Run("unmarshal rejects map", func(t *testing.T) {
+			seq := []Token{{Type: TMapOpen, Length: 3}}
+			slot := &tTuple{}
+			expect := &tTuple{}
+			checkUnmarshalling(t, atl, slot, seq, expect, ErrMalformedTokenStream{TMapOpen, "start of array"})
+		})
+	})
+	t.Run("tuple with trailing optional fields", func(t *testing.T) {
+		type tTuple struct {
+			A string
+			B string `refmt:",omitempty"`
+			C string
+			D string `refmt:",omitempty"`
+			E string `refmt:",omitempty"`
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tTuple{}).StructMap().Autogenerate().AsTuple().Complete(),
+		)
+		t.Run("all fields present", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: 5},
+				TokStr("a"), TokStr("b"), TokStr("c"), TokStr("d"), TokStr("e"),
+				{Type: TArrClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				value := tTuple{"a", "b", "c", "d", "e"}
+				checkMarshalling(t, atl, value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				slot := &tTuple{}
+				expect := &tTuple{"a", "b", "c", "d", "e"}
+				checkUnmarshalling(t, atl, slot, seq, expect, nil)
+			})
+		})
+		t.Run("empty optional field in the middle keeps its position", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: 4},
+				TokStr("a"), TokStr(""), TokStr("c"), TokStr("d"),
+				{Type: TArrClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				value := tTuple{"a", "", "c", "d", ""}
+				checkMarshalling(t, atl, value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				slot := &tTuple{}
+				expect := &tTuple{"a", "", "c", "d", ""}
+				checkUnmarshalling(t, atl, slot, seq, expect, nil)
+			})
+		})
+		t.Run("trailing optional fields absent", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: 3},
+				TokStr("a"), TokStr("b"), TokStr("c"),
+				{Type: TArrClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				value := tTuple{"a", "b", "c", "", ""}
+				checkMarshalling(t, atl, value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				slot := &tTuple{}
+				expect := &tTuple{"a", "b", "c", "", ""}
+				checkUnmarshalling(t, atl, slot, seq, expect, nil)
+			})
+		})
+		t.Run("unmarshal rejects missing required field", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: -1},
+				TokStr("a"), TokStr("b"),
+				{Type: TArrClose},
+			}
+			slot := &tTuple{}
+			expect := &tTuple{"a", "b", "", "", ""}
+			checkUnmarshalling(t, atl, slot, seq, expect, ErrTupleLength{reflect.TypeOf(tTuple{}).String(), 2, 3, 5})
+		})
+		t.Run("unmarshal rejects fewer entries than declared", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: 4},
+				TokStr("a"), TokStr("b"), TokStr("c"),
+				{Type: TArrClose},
+			}
+			slot := &tTuple{}
+			expect := &tTuple{"a", "b", "c", "", ""}
+			checkUnmarshalling(t, atl, slot, seq, expect, fmt.Errorf("malformed array token stream: declared length 4, actually got 3 entries"))
+		})
+		t.Run("unmarshal rejects more entries than declared", func(t *testing.T) {
+			seq := []Token{
+				{Type: TArrOpen, Length: 3},
+				TokStr("a"), TokStr("b"), TokStr("c"), TokStr("d"),
+				{Type: TArrClose},
+			}
+			slot := &tTuple{}
+			expect := &tTuple{"a", "b", "c", "d", ""}
+			checkUnmarshalling(t, atl, slot, seq, expect, fmt.Errorf("malformed array token stream: declared length 3, actually got 4 entries"))
+		})
+	})
+	t.Run("tuples nested in tuples", func(t *testing.T) {
+		type tInner struct {
+			X int
+			Y int
+		}
+		type tOuter struct {
+			Name string
+			Pts  []tInner
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(tOuter{}).StructMap().Autogenerate().AsTuple().Complete(),
+			atlas.BuildEntry(tInner{}).StructMap().Autogenerate().AsTuple().Complete(),
+		)
+		seq := []Token{
+			{Type: TArrOpen, Length: 2},
+			TokStr("line"),
+			{Type: TArrOpen, Length: 2},
+			/**/ {Type: TArrOpen, Length: 2}, TokInt(1), TokInt(2), {Type: TArrClose},
+			/**/ {Type: TArrOpen, Length: 2}, TokInt(3), TokInt(4), {Type: TArrClose},
+			{Type: TArrClose},
+			{Type: TArrClose},
+		}
+		t.Run("marshal", func(t *testing.T) {
+			value := tOuter{"line", []tInner{{1, 2}, {3, 4}}}
+			checkMarshalling(t, atl, value, seq, nil)
+		})
+		t.Run("unmarshal", func(t *testing.T) {
+			slot := &tOuter{}
+			expect := &tOuter{"line", []tInner{{1, 2}, {3, 4}}}
+			checkUnmarshalling(t, atl, slot, seq, expect, nil)
+		})
+	})
+}
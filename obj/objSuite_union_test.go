@@ -1,8 +1,13 @@
 package obj
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
+	. "github.com/warpfork/go-wish"
+
 	"github.com/polydawn/refmt/obj/atlas"
 	. "github.com/polydawn/refmt/tok"
 )
@@ -46,4 +51,326 @@ func TestUnionHandling(t *testing.T) {
 		//   this is inevitable.. but the error messages here need work, because it's extremely easy to typo or just not know about this detail of Go.
 		//checkMarshalling(t, atl, value, seq, nil)
 	})
+	t.Run("hello union inline", func(t *testing.T) {
+		type WowUnion interface{}
+		type WowAlpha struct {
+			A1 string
+			A2 string
+		}
+		type WowBeta struct {
+			B1 string
+			B2 []string
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry((*WowUnion)(nil)).InlineUnion("type").
+				Of(map[string]*atlas.AtlasEntry{
+					"alpha": atlas.BuildEntry(WowAlpha{}).StructMap().Autogenerate().Complete(),
+					"beta":  atlas.BuildEntry(WowBeta{}).StructMap().Autogenerate().Complete(),
+				}),
+		)
+		t.Run("discriminator first", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 3},
+				TokStr("type"), TokStr("alpha"),
+				TokStr("a1"), TokStr("v1"),
+				TokStr("a2"), TokStr("v2"),
+				{Type: TMapClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowAlpha{"v1", "v2"}
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowAlpha{"v1", "v2"}
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("discriminator later in map", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: -1},
+				TokStr("b2"), {Type: TArrOpen, Length: 2},
+				/**/ TokStr("x"), TokStr("y"),
+				/**/ {Type: TArrClose},
+				TokStr("type"), TokStr("beta"),
+				TokStr("b1"), TokStr("v1"),
+				{Type: TMapClose},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowBeta{"v1", []string{"x", "y"}}
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("discriminator missing", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 1},
+				TokStr("a1"), TokStr("v1"),
+				{Type: TMapClose},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrMissingUnionDiscriminator{"type", reflect.TypeOf((*WowUnion)(nil)).Elem().String(), []string{"alpha", "beta"}})
+			})
+		})
+	})
+	t.Run("hello union tagged", func(t *testing.T) {
+		type WowUnion interface{}
+		type WowAlpha struct {
+			A1 string
+			A2 string
+		}
+		type WowBeta string
+		atl := atlas.MustBuild(
+			atlas.BuildEntry((*WowUnion)(nil)).TaggedUnion().
+				Of(
+					atlas.BuildEntry(WowAlpha{}).UseTag(50).StructMap().Autogenerate().Complete(),
+					atlas.BuildEntry(WowBeta("")).UseTag(51).Transform().
+						TransformMarshal(atlas.MakeMarshalTransformFunc(func(x WowBeta) (string, error) { return string(x), nil })).
+						TransformUnmarshal(atlas.MakeUnmarshalTransformFunc(func(x string) (WowBeta, error) { return WowBeta(x), nil })).
+						Complete(),
+				),
+		)
+		t.Run("struct member", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2, Tagged: true, Tag: 50},
+				TokStr("a1"), TokStr("v1"),
+				TokStr("a2"), TokStr("v2"),
+				{Type: TMapClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowAlpha{"v1", "v2"}
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowAlpha{"v1", "v2"}
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("transformed member", func(t *testing.T) {
+			seq := []Token{
+				{Type: TString, Str: "hi", Tagged: true, Tag: 51},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowBeta("hi")
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowBeta("hi")
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("unknown tag", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2, Tagged: true, Tag: 52},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrNoSuchUnionTag{true, 52, reflect.TypeOf((*WowUnion)(nil)).Elem().String(), []int{50, 51}})
+			})
+		})
+		t.Run("untagged", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrNoSuchUnionTag{false, 0, reflect.TypeOf((*WowUnion)(nil)).Elem().String(), []int{50, 51}})
+			})
+		})
+	})
+	t.Run("hello union envelope", func(t *testing.T) {
+		type WowUnion interface{}
+		type WowAlpha struct {
+			A1 string
+			A2 string
+		}
+		type WowBeta []string
+		atl := atlas.MustBuild(
+			atlas.BuildEntry((*WowUnion)(nil)).EnvelopeUnion("kind", "content").
+				Of(map[string]*atlas.AtlasEntry{
+					"alpha": atlas.BuildEntry(WowAlpha{}).StructMap().Autogenerate().Complete(),
+					"beta": atlas.BuildEntry(WowBeta{}).Transform().
+						TransformMarshal(atlas.MakeMarshalTransformFunc(func(x WowBeta) ([]string, error) { return []string(x), nil })).
+						TransformUnmarshal(atlas.MakeUnmarshalTransformFunc(func(x []string) (WowBeta, error) { return WowBeta(x), nil })).
+						Complete(),
+				}),
+		)
+		t.Run("kind first", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2},
+				TokStr("kind"), TokStr("alpha"),
+				TokStr("content"), {Type: TMapOpen, Length: 2},
+				/**/ TokStr("a1"), TokStr("v1"),
+				/**/ TokStr("a2"), TokStr("v2"),
+				/**/ {Type: TMapClose},
+				{Type: TMapClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowAlpha{"v1", "v2"}
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowAlpha{"v1", "v2"}
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("content first", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: -1},
+				TokStr("content"), {Type: TArrOpen, Length: 2},
+				/**/ TokStr("x"), TokStr("y"),
+				/**/ {Type: TArrClose},
+				TokStr("kind"), TokStr("beta"),
+				{Type: TMapClose},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowBeta{"x", "y"}
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("kind missing", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: -1},
+				TokStr("content"), TokStr("x"),
+				{Type: TMapClose},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrMissingUnionDiscriminator{"kind", reflect.TypeOf((*WowUnion)(nil)).Elem().String(), []string{"alpha", "beta"}})
+			})
+		})
+		t.Run("content missing", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: -1},
+				TokStr("kind"), TokStr("alpha"),
+				{Type: TMapClose},
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrMissingUnionContent{"content", reflect.TypeOf((*WowUnion)(nil)).Elem().String()})
+			})
+		})
+		t.Run("unknown key", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2},
+				TokStr("kind"), TokStr("alpha"),
+				TokStr("wat"),
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrNoSuchField{"wat", reflect.TypeOf((*WowUnion)(nil)).Elem().String()})
+			})
+		})
+		t.Run("repeated kind", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: -1},
+				TokStr("kind"), TokStr("alpha"),
+				TokStr("kind"),
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrRepeatedKey{"kind", reflect.TypeOf((*WowUnion)(nil)).Elem().String()})
+			})
+		})
+		t.Run("repeated content", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: -1},
+				TokStr("content"), TokStr("x"),
+				TokStr("content"),
+			}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrRepeatedKey{"content", reflect.TypeOf((*WowUnion)(nil)).Elem().String()})
+			})
+		})
+	})
+	t.Run("envelope union with a repeated member type", func(t *testing.T) {
+		type WowUnion interface{}
+		type WowAlpha struct{ A1 string }
+		defer func() {
+			// Which member comes first depends on map order.
+			msg := fmt.Sprint(recover())
+			Wish(t, strings.HasPrefix(msg, `repeated type obj.WowAlpha in envelope union for "obj.WowUnion"`), ShouldEqual, true)
+		}()
+		atlas.BuildEntry((*WowUnion)(nil)).EnvelopeUnion("kind", "content").
+			Of(map[string]*atlas.AtlasEntry{
+				"a": atlas.BuildEntry(WowAlpha{}).StructMap().Autogenerate().Complete(),
+				"b": atlas.BuildEntry(WowAlpha{}).StructMap().Autogenerate().Complete(),
+			})
+	})
+	t.Run("hello union kinded", func(t *testing.T) {
+		type WowUnion interface{}
+		type WowName string
+		type WowID int
+		type WowSpec struct {
+			Name string
+			Num  int
+		}
+		atl := atlas.MustBuild(
+			atlas.BuildEntry((*WowUnion)(nil)).KindedUnion().
+				Of(map[TokenType]interface{}{
+					TString:  WowName(""),
+					TInt:     WowID(0),
+					TMapOpen: atlas.BuildEntry(WowSpec{}).StructMap().Autogenerate().Complete(),
+				}),
+		)
+		t.Run("string member", func(t *testing.T) {
+			seq := []Token{TokStr("foo")}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowName("foo")
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowName("foo")
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("int member", func(t *testing.T) {
+			seq := []Token{TokInt(4)}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowID(4)
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowID(4)
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+			t.Run("unmarshal from uint", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowID(4)
+				checkUnmarshalling(t, atl, &slot, []Token{{Type: TUint, Uint: 4}}, &expect, nil)
+			})
+		})
+		t.Run("map member", func(t *testing.T) {
+			seq := []Token{
+				{Type: TMapOpen, Length: 2},
+				TokStr("name"), TokStr("foo"),
+				TokStr("num"), TokInt(4),
+				{Type: TMapClose},
+			}
+			t.Run("marshal", func(t *testing.T) {
+				var value WowUnion = WowSpec{"foo", 4}
+				checkMarshalling(t, atl, &value, seq, nil)
+			})
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot WowUnion
+				var expect WowUnion = WowSpec{"foo", 4}
+				checkUnmarshalling(t, atl, &slot, seq, &expect, nil)
+			})
+		})
+		t.Run("unknown kind", func(t *testing.T) {
+			seq := []Token{{Type: TArrOpen, Length: 0}}
+			t.Run("unmarshal", func(t *testing.T) {
+				var slot, expect WowUnion
+				checkUnmarshalling(t, atl, &slot, seq, &expect, ErrNoSuchUnionKind{TArrOpen, reflect.TypeOf((*WowUnion)(nil)).Elem().String(), []TokenType{TInt, TString, TMapOpen}})
+			})
+		})
+	})
 }
@@ -0,0 +1,46 @@
+package obj
+
+import (
+	"strconv"
+)
+
+/*
+	Implemented by machines which handle values with children (structs, maps,
+	arrays, and so on), so that errors can report a path to the child they
+	happened in -- for example `.spec.containers[3].ports["http"]`.
+
+	Machines which delegate to another machine without recursing (unions,
+	transforms, wildcards, etc) implement this by asking their delegate.
+
+	This is only used after an error has occurred, and only on machines which
+	are part way through handling a child -- so the drivers don't need to do
+	any bookkeeping for paths until there's an error to report.
+*/
+type pathStepper interface {
+	appendPathStep(path []byte) []byte
+}
+
+// Appends the path step for the child the machine is working on, if any.
+func appendPathStep(mach interface{}, path []byte) []byte {
+	if ps, ok := mach.(pathStepper); ok {
+		return ps.appendPathStep(path)
+	}
+	return path
+}
+
+func appendPathField(path []byte, name string) []byte {
+	path = append(path, '.')
+	return append(path, name...)
+}
+
+func appendPathIndex(path []byte, i int) []byte {
+	path = append(path, '[')
+	path = strconv.AppendInt(path, int64(i), 10)
+	return append(path, ']')
+}
+
+func appendPathKey(path []byte, key string) []byte {
+	path = append(path, '[')
+	path = strconv.AppendQuote(path, key)
+	return append(path, ']')
+}
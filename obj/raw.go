@@ -0,0 +1,80 @@
+package obj
+
+import (
+	"fmt"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	"github.com/polydawn/refmt/shared"
+	. "github.com/polydawn/refmt/tok"
+)
+
+/*
+	Raw holds a single complete value -- a scalar, or a whole map or array
+	and everything in it -- as the sequence of tokens it was made of,
+	rather than decoding it.  It's the refmt equivalent of `json.RawMessage`,
+	except it's independent of the wire format.
+
+	The unmarshaller fills a Raw by capturing tokens; the marshaller
+	replays a Raw verbatim.  A nil or empty Raw is marshalled as null
+	(and likewise unmarshals, with `Raw.Unmarshal`, as if it was null).
+
+	This is useful for deferring a decision about how to unmarshal part
+	of a document: for example, reading the header of a message first, and
+	then unmarshalling the payload into a type picked based on the header,
+	using `Raw.Unmarshal`.
+*/
+type Raw []Token
+
+/*
+	Unmarshal the captured tokens into `v`, as per the given atlas.
+	This works exactly as if `v` had been unmarshalled in place of the Raw
+	in the first place.
+*/
+func (r Raw) Unmarshal(atl atlas.Atlas, v interface{}) error {
+	unmarshaller := NewUnmarshaller(atl, UnmarshalOptions{})
+	if err := unmarshaller.Bind(v); err != nil {
+		return err
+	}
+	return shared.TokenPump{r.TokenSource(), unmarshaller}.Run()
+}
+
+// TokenSource returns a TokenSource which yields the captured tokens.
+// As when marshalling, a nil or empty Raw yields null.
+func (r Raw) TokenSource() shared.TokenSource {
+	return &rawTokenSource{r, 0}
+}
+
+type rawTokenSource struct {
+	raw Raw
+	i   int
+}
+
+func (src *rawTokenSource) Step(tok *Token) (done bool, err error) {
+	if len(src.raw) == 0 {
+		*tok = Token{Type: TNull}
+		return true, nil
+	}
+	*tok = src.raw[src.i]
+	src.i++
+	return src.i == len(src.raw), nil
+}
+
+// Checks that a Raw holds exactly one complete value.
+func (r Raw) validate() error {
+	depth := 0
+	for i, tok := range r {
+		switch tok.Type {
+		case TMapOpen, TArrOpen:
+			depth++
+		case TMapClose, TArrClose:
+			depth--
+		}
+		if depth < 0 || (depth == 0 && i != len(r)-1) {
+			return fmt.Errorf("raw does not contain a single value: unexpected %s at index %d", tok.Type, i)
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("raw does not contain a single value: %d maps or arrays are unclosed", depth)
+	}
+	return nil
+}
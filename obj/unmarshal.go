@@ -17,8 +17,9 @@ import (
 	Subsequent calls to `Bind` do a full reset, leaving `Step` ready to call
 	again and making all of the machinery reusable without re-allocating.
 */
-func NewUnmarshaller(atl atlas.Atlas) *Unmarshaller {
+func NewUnmarshaller(atl atlas.Atlas, cfg UnmarshalOptions) *Unmarshaller {
 	d := &Unmarshaller{
+		cfg: cfg,
 		unmarshalSlab: unmarshalSlab{
 			atlas: atl,
 			rows:  make([]unmarshalSlabRow, 0, 10),
@@ -43,7 +44,20 @@ func (d *Unmarshaller) Bind(v interface{}) error {
 	return d.step.Reset(&d.unmarshalSlab, rv, rt)
 }
 
+type UnmarshalOptions struct {
+	// If set, a map key which doesn't match any field of the struct being
+	// unmarshalled is skipped, along with its entire value, rather than
+	// raising an ErrNoSuchField.
+	// (This applies to every struct, regardless of their atlas entries;
+	// see also `BuilderStructMap.IgnoreKey` for ignoring specific keys, and
+	// `BuilderStructMap.CaptureUnknownKeys` for keeping them.)
+	// The json and cbor DecodeOptions carry the same setting, which their
+	// Unmarshallers pass on here.
+	IgnoreUnknownFields bool
+}
+
 type Unmarshaller struct {
+	cfg           UnmarshalOptions
 	unmarshalSlab unmarshalSlab
 	stack         []UnmarshalMachine
 	step          UnmarshalMachine
@@ -58,7 +72,7 @@ func (d *Unmarshaller) Step(tok *Token) (bool, error) {
 	done, err := d.step.Step(d, &d.unmarshalSlab, tok)
 	// If the step errored: out, entirely.
 	if err != nil {
-		return true, err
+		return true, d.errAtPath(err)
 	}
 	// If the step wasn't done, return same status.
 	if !done {
@@ -74,6 +88,25 @@ func (d *Unmarshaller) Step(tok *Token) (bool, error) {
 	return false, nil
 }
 
+// Wraps an error with the path to the value being unmarshalled when it occurred.
+// Every machine on the stack is part way through one of its children, so
+// together they describe the path; the machine that raised the error is not
+// asked, since it has no child in progress (or the error would've come from there).
+// Errors at the root are returned unwrapped.
+func (d *Unmarshaller) errAtPath(err error) error {
+	if _, ok := err.(ErrAtPath); ok {
+		return err
+	}
+	var path []byte
+	for _, mach := range d.stack {
+		path = appendPathStep(mach, path)
+	}
+	if len(path) == 0 {
+		return err
+	}
+	return ErrAtPath{string(path), err}
+}
+
 /*
 	Starts the process of recursing unmarshalling over value `rv`.
 
@@ -91,3 +91,7 @@ func (mach *unmarshalMachineArrayWildcard) step_AcceptValueOrClose(driver *Unmar
 	return false, driver.Recurse(tok, rv, mach.value_rt, mach.valueMach)
 	// Step simply remains `step_AcceptValueOrClose` -- arrays don't have much state machine.
 }
+
+func (mach *unmarshalMachineArrayWildcard) appendPathStep(path []byte) []byte {
+	return appendPathIndex(path, mach.index-1)
+}
@@ -0,0 +1,82 @@
+package obj
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Fills a big.Int from any integer token.
+type unmarshalMachineBigInt struct {
+	rv reflect.Value
+}
+
+func (mach *unmarshalMachineBigInt) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	return nil
+}
+
+func (mach *unmarshalMachineBigInt) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	if tok.Type == TNumber {
+		normalized := *tok
+		if err := NormalizeNumber(&normalized); err != nil {
+			return true, err
+		}
+		tok = &normalized
+	}
+	bi := mach.rv.Addr().Interface().(*big.Int)
+	switch tok.Type {
+	case TInt:
+		bi.SetInt64(tok.Int)
+	case TUint:
+		bi.SetUint64(tok.Uint)
+	case TBigInt:
+		bi.Set(tok.BigInt)
+	default:
+		return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
+	}
+	return true, nil
+}
+
+// Fills a big.Float from any numeric token.
+// The precision is whatever is needed to hold the token's value exactly
+// (any precision the big.Float had before is discarded).
+type unmarshalMachineBigFloat struct {
+	rv reflect.Value
+}
+
+func (mach *unmarshalMachineBigFloat) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	return nil
+}
+
+func (mach *unmarshalMachineBigFloat) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	if tok.Type == TNumber {
+		normalized := *tok
+		if err := NormalizeNumber(&normalized); err != nil {
+			return true, err
+		}
+		tok = &normalized
+	}
+	bf := mach.rv.Addr().Interface().(*big.Float)
+	switch tok.Type {
+	case TInt:
+		bf.SetPrec(0).SetInt64(tok.Int)
+	case TUint:
+		bf.SetPrec(0).SetUint64(tok.Uint)
+	case TFloat64:
+		if math.IsNaN(tok.Float64) {
+			return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
+		}
+		bf.SetPrec(0).SetFloat64(tok.Float64)
+	case TBigInt:
+		bf.SetPrec(0).SetInt(tok.BigInt)
+	case TBigFloat:
+		bf.SetPrec(0).Set(tok.BigFloat)
+	default:
+		return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
+	}
+	return true, nil
+}
@@ -2,6 +2,8 @@ package obj
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
 
 	. "github.com/polydawn/refmt/tok"
@@ -49,6 +51,8 @@ func (mach *ptrDerefDelegateUnmarshalMachine) Step(driver *Unmarshaller, slab *u
 	return mach.UnmarshalMachine.Step(driver, slab, tok)
 }
 
+const maxInt = int(^uint(0) >> 1)
+
 type unmarshalMachinePrimitive struct {
 	kind reflect.Kind
 
@@ -60,6 +64,15 @@ func (mach *unmarshalMachinePrimitive) Reset(_ *unmarshalSlab, rv reflect.Value,
 	return nil
 }
 func (mach *unmarshalMachinePrimitive) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	// Numbers kept as text get converted to whichever form fits them,
+	//  unless they're headed into a wildcard, where they stay as they are.
+	if tok.Type == TNumber && mach.kind != reflect.Interface {
+		normalized := *tok
+		if err := NormalizeNumber(&normalized); err != nil {
+			return true, err
+		}
+		tok = &normalized
+	}
 	switch mach.kind {
 	case reflect.Bool:
 		switch tok.Type {
@@ -85,6 +98,12 @@ func (mach *unmarshalMachinePrimitive) Step(_ *Unmarshaller, _ *unmarshalSlab, t
 		case TUint:
 			mach.rv.SetInt(int64(tok.Uint)) // todo: overflow check
 			return true, nil
+		case TBigInt:
+			if tok.BigInt.IsInt64() {
+				mach.rv.SetInt(tok.BigInt.Int64()) // todo: overflow check
+				return true, nil
+			}
+			return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
 		default:
 			return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
 		}
@@ -99,6 +118,12 @@ func (mach *unmarshalMachinePrimitive) Step(_ *Unmarshaller, _ *unmarshalSlab, t
 		case TUint:
 			mach.rv.SetUint(tok.Uint)
 			return true, nil
+		case TBigInt:
+			if tok.BigInt.IsUint64() {
+				mach.rv.SetUint(tok.BigInt.Uint64()) // todo: overflow check
+				return true, nil
+			}
+			return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
 		default:
 			return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
 		}
@@ -113,6 +138,18 @@ func (mach *unmarshalMachinePrimitive) Step(_ *Unmarshaller, _ *unmarshalSlab, t
 		case TUint:
 			mach.rv.SetFloat(float64(tok.Uint))
 			return true, nil
+		case TBigInt:
+			f, _ := new(big.Float).SetInt(tok.BigInt).Float64()
+			mach.rv.SetFloat(f)
+			return true, nil
+		case TBigFloat:
+			// Out of range even for a float64 means we'd only be able to set an infinity.
+			f, _ := tok.BigFloat.Float64()
+			if math.IsInf(f, 0) && !tok.BigFloat.IsInf() {
+				return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
+			}
+			mach.rv.SetFloat(f)
+			return true, nil
 		default:
 			return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
 		}
@@ -161,9 +198,19 @@ func (mach *unmarshalMachinePrimitive) Step(_ *Unmarshaller, _ *unmarshalSlab, t
 		case TInt:
 			mach.rv.Set(reflect.ValueOf(int(tok.Int))) // Unmarshalling with no particular type info should default to using plain 'int' whenever viable.
 		case TUint:
+			if tok.Uint > uint64(maxInt) {
+				mach.rv.Set(reflect.ValueOf(tok.Uint)) // Not viable as an 'int'; keep every bit.
+				break
+			}
 			mach.rv.Set(reflect.ValueOf(int(tok.Uint))) // Unmarshalling with no particular type info should default to using plain 'int' whenever viable.
 		case TFloat64:
 			mach.rv.Set(reflect.ValueOf(tok.Float64))
+		case TBigInt:
+			mach.rv.Set(reflect.ValueOf(new(big.Int).Set(tok.BigInt)))
+		case TBigFloat:
+			mach.rv.Set(reflect.ValueOf(new(big.Float).Set(tok.BigFloat)))
+		case TNumber:
+			mach.rv.Set(reflect.ValueOf(Number(tok.Str)))
 		case TNull:
 			mach.rv.Set(reflect.ValueOf(nil))
 		default: // any of the other token types should not have been routed here to begin with.
@@ -174,3 +221,7 @@ func (mach *unmarshalMachinePrimitive) Step(_ *Unmarshaller, _ *unmarshalSlab, t
 		panic(fmt.Errorf("unhandled: %v", mach.kind))
 	}
 }
+
+func (mach *ptrDerefDelegateUnmarshalMachine) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.UnmarshalMachine, path)
+}
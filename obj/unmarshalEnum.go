@@ -0,0 +1,35 @@
+package obj
+
+import (
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type unmarshalMachineEnum struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	target_rv reflect.Value
+}
+
+func (mach *unmarshalMachineEnum) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.target_rv = rv
+	return nil
+}
+
+func (mach *unmarshalMachineEnum) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	if tok.Type != TString {
+		return true, ErrUnmarshalTypeCantFit{*tok, mach.target_rv, 0}
+	}
+	cfg := mach.cfg.EnumMorphism
+	value_rv, ok := cfg.Elements[tok.Str]
+	if !ok {
+		if !cfg.UseFallback {
+			return true, ErrNoSuchEnumMember{tok.Str, mach.cfg.Type.String(), cfg.KnownMembers}
+		}
+		value_rv = cfg.Elements[cfg.Fallback]
+	}
+	mach.target_rv.Set(value_rv)
+	return true, nil
+}
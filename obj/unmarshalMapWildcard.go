@@ -149,3 +149,10 @@ func (mach *unmarshalMachineMapStringWildcard) step_AcceptAnotherKeyOrClose(_ *U
 	// The rest is the same as the very first acceptKeyOrClose (and has the same future state transitions).
 	return mach.step_AcceptKeyOrClose(nil, slab, tok)
 }
+
+func (mach *unmarshalMachineMapStringWildcard) appendPathStep(path []byte) []byte {
+	if mach.key_rv.Kind() == reflect.String {
+		return appendPathKey(path, mach.key_rv.String())
+	}
+	return appendPathKey(path, fmt.Sprint(mach.key_rv.Interface()))
+}
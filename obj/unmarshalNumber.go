@@ -0,0 +1,40 @@
+package obj
+
+import (
+	"reflect"
+	"strconv"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Fills a Number from any numeric token.
+// Tokens that already hold the number's text keep it exactly;
+// others are formatted in the shortest form that reads back as the same value.
+type unmarshalMachineNumber struct {
+	rv reflect.Value
+}
+
+func (mach *unmarshalMachineNumber) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	return nil
+}
+
+func (mach *unmarshalMachineNumber) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TNumber:
+		mach.rv.SetString(tok.Str)
+	case TInt:
+		mach.rv.SetString(strconv.FormatInt(tok.Int, 10))
+	case TUint:
+		mach.rv.SetString(strconv.FormatUint(tok.Uint, 10))
+	case TFloat64:
+		mach.rv.SetString(strconv.FormatFloat(tok.Float64, 'g', -1, 64))
+	case TBigInt:
+		mach.rv.SetString(tok.BigInt.String())
+	case TBigFloat:
+		mach.rv.SetString(tok.BigFloat.Text('g', -1))
+	default:
+		return true, ErrUnmarshalTypeCantFit{*tok, mach.rv, 0}
+	}
+	return true, nil
+}
@@ -0,0 +1,39 @@
+package obj
+
+import (
+	"reflect"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Fills a Raw by capturing all the tokens of one complete value.
+type unmarshalMachineRaw struct {
+	target_rv reflect.Value
+	raw       Raw
+	depth     int // Depth of nesting.  Zero means we're done, once the current token is captured.
+}
+
+func (mach *unmarshalMachineRaw) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.target_rv = rv
+	mach.raw = nil // Never reuse: the last one we filled is out there in the wild.
+	mach.depth = 0
+	return nil
+}
+
+func (mach *unmarshalMachineRaw) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		mach.depth++
+	case TMapClose, TArrClose:
+		mach.depth--
+		if mach.depth < 0 {
+			return true, ErrMalformedTokenStream{tok.Type, "start of value"}
+		}
+	}
+	mach.raw = appendTokenCopy(mach.raw, tok)
+	if mach.depth > 0 {
+		return false, nil
+	}
+	mach.target_rv.Set(reflect.ValueOf(mach.raw))
+	return true, nil
+}
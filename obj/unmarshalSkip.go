@@ -0,0 +1,31 @@
+package obj
+
+import (
+	"reflect"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+// Consumes one complete value -- scalar, or an entire map or array including
+// everything nested in it -- from the token stream, and discards it.
+type unmarshalMachineSkip struct {
+	depth int // Depth of nesting.  Zero means we're done, once the current token is consumed.
+}
+
+func (mach *unmarshalMachineSkip) Reset(_ *unmarshalSlab, _ reflect.Value, _ reflect.Type) error {
+	mach.depth = 0
+	return nil
+}
+
+func (mach *unmarshalMachineSkip) Step(_ *Unmarshaller, _ *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		mach.depth++
+	case TMapClose, TArrClose:
+		mach.depth--
+		if mach.depth < 0 {
+			return true, ErrMalformedTokenStream{tok.Type, "start of value"}
+		}
+	}
+	return mach.depth == 0, nil
+}
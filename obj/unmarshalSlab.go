@@ -26,8 +26,19 @@ type unmarshalSlabRow struct {
 	unmarshalMachineSliceWildcard
 	unmarshalMachineArrayWildcard
 	unmarshalMachineStructAtlas
+	unmarshalMachineStructTupleAtlas
 	unmarshalMachineTransform
 	unmarshalMachineUnionKeyed
+	unmarshalMachineUnionInline
+	unmarshalMachineUnionTagged
+	unmarshalMachineUnionEnvelope
+	unmarshalMachineUnionKinded
+	unmarshalMachineEnum
+	unmarshalMachineSkip
+	unmarshalMachineRaw
+	unmarshalMachineBigInt
+	unmarshalMachineBigFloat
+	unmarshalMachineNumber
 
 	errThunkUnmarshalMachine
 }
@@ -72,6 +83,16 @@ func (slab *unmarshalSlab) requisitionMachine(rt reflect.Type) UnmarshalMachine
 	return &row.ptrDerefDelegateUnmarshalMachine
 }
 
+/*
+	Return a reference to a machine which will consume (and discard) a value.
+	*You must release() when done.*
+*/
+func (slab *unmarshalSlab) requisitionSkipMachine() UnmarshalMachine {
+	off := len(slab.rows)
+	slab.grow()
+	return &slab.rows[off].unmarshalMachineSkip
+}
+
 func _yieldUnmarshalMachinePtr(row *unmarshalSlabRow, atl atlas.Atlas, rt reflect.Type) UnmarshalMachine {
 	rtid := reflect.ValueOf(rt).Pointer()
 
@@ -85,6 +106,14 @@ func _yieldUnmarshalMachinePtr(row *unmarshalSlabRow, atl atlas.Atlas, rt reflec
 		rtid_bytes:
 		row.unmarshalMachinePrimitive.kind = rt.Kind()
 		return &row.unmarshalMachinePrimitive
+	case rtid_raw:
+		return &row.unmarshalMachineRaw
+	case rtid_bigInt:
+		return &row.unmarshalMachineBigInt
+	case rtid_bigFloat:
+		return &row.unmarshalMachineBigFloat
+	case rtid_number:
+		return &row.unmarshalMachineNumber
 	}
 
 	// Consult atlas second.
@@ -150,12 +179,30 @@ func _yieldUnmarshalMachinePtrForAtlasEntry(row *unmarshalSlabRow, entry *atlas.
 		// Pick delegate without growing stack.  (This currently means recursive transform won't fly.)
 		row.unmarshalMachineTransform.delegate = _yieldUnmarshalMachinePtr(row, atl, entry.UnmarshalTransformTargetType)
 		return &row.unmarshalMachineTransform
+	case entry.StructMap != nil && entry.StructMap.Tuple:
+		row.unmarshalMachineStructTupleAtlas.cfg = entry
+		return &row.unmarshalMachineStructTupleAtlas
 	case entry.StructMap != nil:
 		row.unmarshalMachineStructAtlas.cfg = entry
 		return &row.unmarshalMachineStructAtlas
 	case entry.UnionKeyedMorphism != nil:
 		row.unmarshalMachineUnionKeyed.cfg = entry.UnionKeyedMorphism
 		return &row.unmarshalMachineUnionKeyed
+	case entry.UnionInlineMorphism != nil:
+		row.unmarshalMachineUnionInline.cfg = entry.UnionInlineMorphism
+		return &row.unmarshalMachineUnionInline
+	case entry.UnionTaggedMorphism != nil:
+		row.unmarshalMachineUnionTagged.cfg = entry.UnionTaggedMorphism
+		return &row.unmarshalMachineUnionTagged
+	case entry.UnionEnvelopeMorphism != nil:
+		row.unmarshalMachineUnionEnvelope.cfg = entry.UnionEnvelopeMorphism
+		return &row.unmarshalMachineUnionEnvelope
+	case entry.UnionKindedMorphism != nil:
+		row.unmarshalMachineUnionKinded.cfg = entry.UnionKindedMorphism
+		return &row.unmarshalMachineUnionKinded
+	case entry.EnumMorphism != nil:
+		row.unmarshalMachineEnum.cfg = entry
+		return &row.unmarshalMachineEnum
 	default:
 		panic("invalid atlas entry")
 	}
@@ -85,3 +85,7 @@ func (mach *unmarshalMachineSliceWildcard) step_AcceptValueOrClose(driver *Unmar
 	return false, driver.Recurse(tok, rv, mach.value_rt, mach.valueMach)
 	// Step simply remains `step_AcceptValueOrClose` -- arrays don't have much state machine.
 }
+
+func (mach *unmarshalMachineSliceWildcard) appendPathStep(path []byte) []byte {
+	return appendPathIndex(path, mach.index-1)
+}
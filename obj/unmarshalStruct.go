@@ -16,6 +16,12 @@ type unmarshalMachineStructAtlas struct {
 	index      int                  // Progress marker: our distance into the stream of pairs.
 	value      bool                 // Progress marker: whether the next token is a value.
 	fieldEntry atlas.StructMapEntry // Which field we expect next: set when consuming a key.
+	seen       []bool               // Which of the Fields have had a key, so far.  Used to check Required fields.
+
+	extra_rv reflect.Value // The map capturing unknown keys, if the StructMap has an Extra field.
+	extraKey string        // Set when consuming an unknown key, if capturing them.
+	extraTmp reflect.Value // Slot for the value of an unknown key; stored into the map once complete.
+	isExtra  bool          // Whether the current entry is an unknown key being captured.
 }
 
 func (mach *unmarshalMachineStructAtlas) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
@@ -23,6 +29,7 @@ func (mach *unmarshalMachineStructAtlas) Reset(_ *unmarshalSlab, rv reflect.Valu
 	// not necessary to reset expectLen because MapOpen tokens also consistently use the -1 convention.
 	mach.index = -1
 	mach.value = false
+	mach.isExtra = false
 	return nil
 }
 
@@ -34,6 +41,16 @@ func (mach *unmarshalMachineStructAtlas) Step(driver *Unmarshaller, slab *unmars
 			// Great.  Consumed.
 			mach.expectLen = tok.Length
 			mach.index++
+			mach.seen = mach.seen[:0]
+			for range mach.cfg.StructMap.Fields {
+				mach.seen = append(mach.seen, false)
+			}
+			// The extra field should hold exactly the unknown keys from this map;
+			//  clear anything left there from before.
+			if extra := mach.cfg.StructMap.Extra; extra != nil {
+				mach.extra_rv = extra.ReflectRoute.TraverseToValue(mach.rv)
+				mach.extra_rv.Set(reflect.Zero(extra.Type))
+			}
 			return false, nil
 		case TMapClose:
 			return true, ErrMalformedTokenStream{tok.Type, "start of map"}
@@ -51,18 +68,22 @@ func (mach *unmarshalMachineStructAtlas) Step(driver *Unmarshaller, slab *unmars
 
 	// Accept value:
 	if mach.value {
+		mach.index++
+		mach.value = false
+		if mach.fieldEntry.Ignore {
+			// Consume and discard the value, however deep it goes.
+			return false, driver.Recurse(tok, reflect.Value{}, nil, slab.requisitionSkipMachine())
+		}
 		var child_rv reflect.Value
 		var child_rt reflect.Type
-		if mach.fieldEntry.Ignore {
-			// Use a dummy slot to slurp up the value.  This could be more efficient.
-			child_rt = reflect.TypeOf((*interface{})(nil)).Elem()
+		if mach.isExtra {
+			child_rt = mach.cfg.StructMap.Extra.Type.Elem()
 			child_rv = reflect.New(child_rt).Elem()
+			mach.extraTmp = child_rv
 		} else {
 			child_rt = mach.fieldEntry.Type
 			child_rv = mach.fieldEntry.ReflectRoute.TraverseToValue(mach.rv)
 		}
-		mach.index++
-		mach.value = false
 		return false, driver.Recurse(
 			tok,
 			child_rv,
@@ -75,6 +96,13 @@ func (mach *unmarshalMachineStructAtlas) Step(driver *Unmarshaller, slab *unmars
 	if mach.index > 0 {
 		slab.release()
 	}
+	if mach.isExtra {
+		if mach.extra_rv.IsNil() {
+			mach.extra_rv.Set(reflect.MakeMap(mach.extra_rv.Type()))
+		}
+		mach.extra_rv.SetMapIndex(reflect.ValueOf(mach.extraKey).Convert(mach.extra_rv.Type().Key()), mach.extraTmp)
+		mach.isExtra = false
+	}
 	switch tok.Type {
 	case TMapClose:
 		// If we got length header, validate that; error if mismatch.
@@ -84,8 +112,29 @@ func (mach *unmarshalMachineStructAtlas) Step(driver *Unmarshaller, slab *unmars
 			}
 		}
 
-		// Future: this would be a reasonable place to check that all required fields have been filled in, if we add such a feature.
-
+		// Check that all required fields have been filled in, and fill in defaults for the rest.
+		var missing []string
+		for n, fieldEntry := range mach.cfg.StructMap.Fields {
+			if mach.seen[n] {
+				continue
+			}
+			if fieldEntry.Required {
+				missing = append(missing, fieldEntry.SerialName)
+				continue
+			}
+			if fieldEntry.HasDefault() {
+				def_rv, err := structFieldDefault(fieldEntry)
+				if err != nil {
+					return true, err
+				}
+				if field_rv := fieldEntry.ReflectRoute.TraverseToValue(mach.rv); field_rv.IsValid() {
+					field_rv.Set(def_rv)
+				}
+			}
+		}
+		if missing != nil {
+			return true, ErrMissingField{missing, mach.cfg.Type.String()}
+		}
 		return true, nil
 	case TString:
 		for n := 0; n < len(mach.cfg.StructMap.Fields); n++ {
@@ -94,16 +143,66 @@ func (mach *unmarshalMachineStructAtlas) Step(driver *Unmarshaller, slab *unmars
 				continue
 			}
 			mach.fieldEntry = fieldEntry
+			mach.seen[n] = true
 			mach.value = true
 			break
 		}
 		if mach.value == false {
-			// FUTURE: it should be configurable per atlas.StructMap whether this is considered an error or to be tolerated.
-			// Currently we're being extremely strict about it, which is a divergence from the stdlib json behavior.
-			return true, ErrNoSuchField{tok.Str, mach.cfg.Type.String()}
+			// Unknown keys are captured if the StructMap designates a field for them,
+			// or skipped if the unmarshaller is configured to ignore them.
+			// Otherwise we're being extremely strict about it, which is a divergence from the stdlib json behavior.
+			switch {
+			case mach.cfg.StructMap.Extra != nil:
+				mach.extraKey = tok.Str
+				mach.isExtra = true
+			case driver.cfg.IgnoreUnknownFields:
+				mach.fieldEntry = atlas.StructMapEntry{SerialName: tok.Str, Ignore: true}
+			default:
+				return true, ErrNoSuchField{tok.Str, mach.cfg.Type.String()}
+			}
+			mach.value = true
 		}
 	default:
 		return true, ErrMalformedTokenStream{tok.Type, "map key"}
 	}
 	return false, nil
 }
+
+// Yields the default value for a field, converted to the field's type.
+func structFieldDefault(fieldEntry atlas.StructMapEntry) (reflect.Value, error) {
+	def := fieldEntry.Default
+	if fieldEntry.DefaultFunc != nil {
+		def = fieldEntry.DefaultFunc()
+	}
+	def_rv := reflect.ValueOf(def)
+	switch {
+	case !def_rv.IsValid():
+		return reflect.Zero(fieldEntry.Type), nil
+	case def_rv.Type().AssignableTo(fieldEntry.Type):
+		return def_rv, nil
+	case def_rv.Type().ConvertibleTo(fieldEntry.Type) && isNumberKind(def_rv.Kind()) == isNumberKind(fieldEntry.Type.Kind()):
+		// Conversions are for the sake of untyped constants (e.g. `Default: 8080` for a uint16 field),
+		//  so we don't allow the ones that'd turn numbers into strings.
+		return def_rv.Convert(fieldEntry.Type), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("default for field %q is of type %s, which cannot be used as %s", fieldEntry.SerialName, def_rv.Type(), fieldEntry.Type)
+	}
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func (mach *unmarshalMachineStructAtlas) appendPathStep(path []byte) []byte {
+	if mach.isExtra {
+		return appendPathField(path, mach.extraKey)
+	}
+	return appendPathField(path, mach.fieldEntry.SerialName)
+}
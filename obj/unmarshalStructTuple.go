@@ -0,0 +1,106 @@
+package obj
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type unmarshalMachineStructTupleAtlas struct {
+	cfg *atlas.AtlasEntry // set on initialization
+
+	rv        reflect.Value
+	index     int // Progress marker: our distance into the fields list.
+	count     int // Progress marker: our distance into the stream of entries.
+	minLen    int // Number of entries up to and including the last non-optional field.
+	maxLen    int // Number of entries if every field is present.
+	expectLen int // Length header from arrOpen token.  If it was set, we validate it.
+}
+
+func (mach *unmarshalMachineStructTupleAtlas) Reset(_ *unmarshalSlab, rv reflect.Value, _ reflect.Type) error {
+	mach.rv = rv
+	mach.index = -1
+	mach.count = 0
+	mach.minLen, mach.maxLen = tupleLengthBounds(mach.cfg)
+	return nil
+}
+
+func (mach *unmarshalMachineStructTupleAtlas) Step(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	// Starter state.
+	if mach.index < 0 {
+		switch tok.Type {
+		case TArrOpen:
+			// Great.  Consumed.
+			// If we got length header, validate that up front.
+			if tok.Length >= 0 && (tok.Length < mach.minLen || tok.Length > mach.maxLen) {
+				return true, ErrTupleLength{mach.cfg.Type.String(), tok.Length, mach.minLen, mach.maxLen}
+			}
+			mach.expectLen = tok.Length
+			mach.index++
+			return false, nil
+		case TNull:
+			mach.rv.Set(reflect.Zero(mach.rv.Type()))
+			return true, nil
+		default:
+			return true, ErrMalformedTokenStream{tok.Type, "start of array"}
+		}
+	}
+
+	// Release the slab row from the previous value, if any.
+	if mach.count > 0 {
+		slab.release()
+	}
+
+	// Accept value or end:
+	switch tok.Type {
+	case TArrClose:
+		// If we got length header, validate that; error if mismatch.
+		if mach.expectLen >= 0 && mach.expectLen != mach.count {
+			return true, fmt.Errorf("malformed array token stream: declared length %d, actually got %d entries", mach.expectLen, mach.count)
+		}
+		if mach.count < mach.minLen {
+			return true, ErrTupleLength{mach.cfg.Type.String(), mach.count, mach.minLen, mach.maxLen}
+		}
+		return true, nil
+	case TMapClose:
+		return true, ErrMalformedTokenStream{tok.Type, "start of value or end of array"}
+	}
+	nFields := len(mach.cfg.StructMap.Fields)
+	for mach.index < nFields && mach.cfg.StructMap.Fields[mach.index].Ignore {
+		mach.index++
+	}
+	if mach.index == nFields {
+		return true, ErrTupleLength{mach.cfg.Type.String(), mach.count + 1, mach.minLen, mach.maxLen}
+	}
+	fieldEntry := mach.cfg.StructMap.Fields[mach.index]
+	mach.index++
+	mach.count++
+	return false, driver.Recurse(
+		tok,
+		fieldEntry.ReflectRoute.TraverseToValue(mach.rv),
+		fieldEntry.Type,
+		slab.requisitionMachine(fieldEntry.Type),
+	)
+}
+
+// Compute the minimum and maximum number of entries acceptable for a
+// tuple-style struct.  Ignored fields don't count; trailing omitEmpty
+// fields count only towards the maximum.
+func tupleLengthBounds(cfg *atlas.AtlasEntry) (minLen, maxLen int) {
+	for _, fieldEntry := range cfg.StructMap.Fields {
+		if fieldEntry.Ignore {
+			continue
+		}
+		maxLen++
+		if !fieldEntry.OmitEmpty {
+			minLen = maxLen
+		}
+	}
+	return
+}
+
+func (mach *unmarshalMachineStructTupleAtlas) appendPathStep(path []byte) []byte {
+	return appendPathIndex(path, mach.count-1)
+}
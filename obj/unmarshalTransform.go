@@ -36,3 +36,7 @@ func (mach *unmarshalMachineTransform) Step(driver *Unmarshaller, slab *unmarsha
 	mach.target_rv.Set(tr_rv)
 	return true, err
 }
+
+func (mach *unmarshalMachineTransform) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
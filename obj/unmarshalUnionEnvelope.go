@@ -0,0 +1,179 @@
+package obj
+
+import (
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type unmarshalMachineUnionEnvelope struct {
+	cfg *atlas.UnionEnvelopeMorphism // set on initialization
+
+	target_rv reflect.Value
+	target_rt reflect.Type
+
+	phase      unmarshalMachineUnionEnvelopePhase
+	sawKind    bool
+	sawContent bool
+	buffer     []Token // Tokens of the content, if it came before the kind; must be replayed to the delegate.
+	depth      int     // Depth of nesting, while buffering.
+	tmp_rv     reflect.Value
+	delegate   UnmarshalMachine // actual machine, once we've demuxed with the kind.
+}
+
+type unmarshalMachineUnionEnvelopePhase uint8
+
+const (
+	unmarshalMachineUnionEnvelopePhase_acceptMapOpen unmarshalMachineUnionEnvelopePhase = iota
+	unmarshalMachineUnionEnvelopePhase_acceptKey
+	unmarshalMachineUnionEnvelopePhase_acceptKind
+	unmarshalMachineUnionEnvelopePhase_bufferContent
+	unmarshalMachineUnionEnvelopePhase_delegate
+)
+
+func (mach *unmarshalMachineUnionEnvelope) Reset(_ *unmarshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv
+	mach.target_rt = rt
+	mach.phase = unmarshalMachineUnionEnvelopePhase_acceptMapOpen
+	mach.sawKind = false
+	mach.sawContent = false
+	mach.buffer = mach.buffer[:0]
+	mach.depth = 0
+	return nil
+}
+
+func (mach *unmarshalMachineUnionEnvelope) Step(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch mach.phase {
+	case unmarshalMachineUnionEnvelopePhase_acceptMapOpen:
+		return mach.step_acceptMapOpen(driver, slab, tok)
+	case unmarshalMachineUnionEnvelopePhase_acceptKey:
+		return mach.step_acceptKey(driver, slab, tok)
+	case unmarshalMachineUnionEnvelopePhase_acceptKind:
+		return mach.step_acceptKind(driver, slab, tok)
+	case unmarshalMachineUnionEnvelopePhase_bufferContent:
+		return mach.step_bufferContent(driver, slab, tok)
+	case unmarshalMachineUnionEnvelopePhase_delegate:
+		return mach.step_delegate(driver, slab, tok)
+	}
+	panic("unreachable")
+}
+
+func (mach *unmarshalMachineUnionEnvelope) step_acceptMapOpen(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen:
+		switch tok.Length {
+		case -1: // pass
+		case 2: // correct
+		default:
+			return true, ErrMalformedTokenStream{tok.Type, "unions in envelope format must be maps with exactly two entries"} // FIXME not malformed per se
+		}
+		mach.phase = unmarshalMachineUnionEnvelopePhase_acceptKey
+		return false, nil
+	// REVIEW: is case TNull perhaps conditionally acceptable?
+	default:
+		return true, ErrMalformedTokenStream{tok.Type, "start of union value"} // FIXME not malformed per se
+	}
+}
+
+func (mach *unmarshalMachineUnionEnvelope) step_acceptKey(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TString:
+		switch tok.Str {
+		case mach.cfg.KindKey:
+			if mach.sawKind {
+				return true, ErrRepeatedKey{tok.Str, mach.target_rt.String()}
+			}
+			mach.sawKind = true
+			mach.phase = unmarshalMachineUnionEnvelopePhase_acceptKind
+			return false, nil
+		case mach.cfg.ContentKey:
+			if mach.sawContent {
+				return true, ErrRepeatedKey{tok.Str, mach.target_rt.String()}
+			}
+			mach.sawContent = true
+			if mach.delegate == nil {
+				mach.phase = unmarshalMachineUnionEnvelopePhase_bufferContent
+			} else {
+				mach.phase = unmarshalMachineUnionEnvelopePhase_delegate
+			}
+			return false, nil
+		default:
+			return true, ErrNoSuchField{tok.Str, mach.target_rt.String()}
+		}
+	case TMapClose:
+		if !mach.sawKind {
+			return true, ErrMissingUnionDiscriminator{mach.cfg.KindKey, mach.target_rt.String(), mach.cfg.KnownMembers}
+		}
+		if !mach.sawContent {
+			return true, ErrMissingUnionContent{mach.cfg.ContentKey, mach.target_rt.String()}
+		}
+		mach.target_rv.Set(mach.tmp_rv)
+		return true, nil
+	default:
+		return true, ErrMalformedTokenStream{tok.Type, "map key"}
+	}
+}
+
+func (mach *unmarshalMachineUnionEnvelope) step_acceptKind(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	if tok.Type != TString {
+		return true, ErrMalformedTokenStream{tok.Type, "string naming the union member"}
+	}
+	// Look up the configuration for this kind.
+	delegateAtlasEnt, ok := mach.cfg.Elements[tok.Str]
+	if !ok {
+		return true, ErrNoSuchUnionMember{tok.Str, mach.target_rt, mach.cfg.KnownMembers}
+	}
+	// Allocate a new concrete value, and hang on to that rv handle.
+	//  Assigning into the interface must be done at the end if it's a non-pointer.
+	mach.tmp_rv = reflect.New(delegateAtlasEnt.Type).Elem()
+	// Get and configure a machine for the delegation.
+	delegate := _yieldUnmarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	if err := delegate.Reset(slab, mach.tmp_rv, delegateAtlasEnt.Type); err != nil {
+		return true, err
+	}
+	mach.delegate = delegate
+
+	// If we already buffered the content, replay it now.
+	//  These go through the driver (which routes right back to us, then our delegate)
+	//  because the delegate may recurse, and if so, the driver has to route to those machines.
+	if !mach.sawContent {
+		mach.phase = unmarshalMachineUnionEnvelopePhase_acceptKey
+		return false, nil
+	}
+	mach.phase = unmarshalMachineUnionEnvelopePhase_delegate
+	for i := range mach.buffer {
+		if _, err := driver.Step(&mach.buffer[i]); err != nil {
+			return true, err
+		}
+	}
+	mach.buffer = mach.buffer[:0]
+	return false, nil
+}
+
+func (mach *unmarshalMachineUnionEnvelope) step_bufferContent(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		mach.depth++
+	case TMapClose, TArrClose:
+		mach.depth--
+	}
+	mach.buffer = appendTokenCopy(mach.buffer, tok)
+	if mach.depth == 0 {
+		mach.phase = unmarshalMachineUnionEnvelopePhase_acceptKey
+	}
+	return false, nil
+}
+
+func (mach *unmarshalMachineUnionEnvelope) step_delegate(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	done, err = mach.delegate.Step(driver, slab, tok)
+	if done && err == nil {
+		mach.phase = unmarshalMachineUnionEnvelopePhase_acceptKey
+		return false, nil
+	}
+	return
+}
+
+func (mach *unmarshalMachineUnionEnvelope) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -0,0 +1,162 @@
+package obj
+
+import (
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type unmarshalMachineUnionInline struct {
+	cfg *atlas.UnionInlineMorphism // set on initialization
+
+	target_rv reflect.Value
+	target_rt reflect.Type
+
+	phase     unmarshalMachineUnionInlinePhase
+	expectLen int     // Length header from mapOpen token; passed on to the delegate (minus one, for the discriminator).
+	buffer    []Token // Tokens seen before the discriminator, which must be replayed to the delegate.
+	depth     int     // Depth of nesting, while buffering.  Zero means we're looking at our own map's keys and values.
+	isValue   bool    // While buffering at depth zero: whether the next token is a value.
+	tmp_rv    reflect.Value
+	delegate  UnmarshalMachine // actual machine, once we've demuxed with the discriminator.
+}
+
+type unmarshalMachineUnionInlinePhase uint8
+
+const (
+	unmarshalMachineUnionInlinePhase_acceptMapOpen unmarshalMachineUnionInlinePhase = iota
+	unmarshalMachineUnionInlinePhase_scanForDiscriminatorKey
+	unmarshalMachineUnionInlinePhase_acceptDiscriminator
+	unmarshalMachineUnionInlinePhase_delegate
+)
+
+func (mach *unmarshalMachineUnionInline) Reset(_ *unmarshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv
+	mach.target_rt = rt
+	mach.phase = unmarshalMachineUnionInlinePhase_acceptMapOpen
+	mach.buffer = mach.buffer[:0]
+	mach.depth = 0
+	mach.isValue = false
+	return nil
+}
+
+func (mach *unmarshalMachineUnionInline) Step(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch mach.phase {
+	case unmarshalMachineUnionInlinePhase_acceptMapOpen:
+		return mach.step_acceptMapOpen(driver, slab, tok)
+	case unmarshalMachineUnionInlinePhase_scanForDiscriminatorKey:
+		return mach.step_scanForDiscriminatorKey(driver, slab, tok)
+	case unmarshalMachineUnionInlinePhase_acceptDiscriminator:
+		return mach.step_acceptDiscriminator(driver, slab, tok)
+	case unmarshalMachineUnionInlinePhase_delegate:
+		return mach.step_delegate(driver, slab, tok)
+	}
+	panic("unreachable")
+}
+
+func (mach *unmarshalMachineUnionInline) step_acceptMapOpen(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen:
+		mach.expectLen = tok.Length
+		mach.phase = unmarshalMachineUnionInlinePhase_scanForDiscriminatorKey
+		return false, nil
+	// REVIEW: is case TNull perhaps conditionally acceptable?
+	default:
+		return true, ErrMalformedTokenStream{tok.Type, "start of union value"} // FIXME not malformed per se
+	}
+}
+
+// Looks for the discriminator key among the map keys,
+// buffering any other entries that come before it.
+func (mach *unmarshalMachineUnionInline) step_scanForDiscriminatorKey(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	if mach.depth == 0 && !mach.isValue {
+		switch tok.Type {
+		case TString:
+			if tok.Str == mach.cfg.DiscriminatorKey {
+				mach.phase = unmarshalMachineUnionInlinePhase_acceptDiscriminator
+				return false, nil
+			}
+		case TMapClose:
+			return true, ErrMissingUnionDiscriminator{mach.cfg.DiscriminatorKey, mach.target_rt.String(), mach.cfg.KnownMembers}
+		default:
+			return true, ErrMalformedTokenStream{tok.Type, "map key"}
+		}
+	}
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		mach.depth++
+	case TMapClose, TArrClose:
+		mach.depth--
+	}
+	if mach.depth == 0 {
+		mach.isValue = !mach.isValue
+	}
+	mach.buffer = appendTokenCopy(mach.buffer, tok)
+	return false, nil
+}
+
+func (mach *unmarshalMachineUnionInline) step_acceptDiscriminator(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	if tok.Type != TString {
+		return true, ErrMalformedTokenStream{tok.Type, "string naming the union member"}
+	}
+	// Look up the configuration for this discriminator.
+	delegateAtlasEnt, ok := mach.cfg.Elements[tok.Str]
+	if !ok {
+		return true, ErrNoSuchUnionMember{tok.Str, mach.target_rt, mach.cfg.KnownMembers}
+	}
+	// Allocate a new concrete value, and hang on to that rv handle.
+	//  Assigning into the interface must be done at the end if it's a non-pointer.
+	mach.tmp_rv = reflect.New(delegateAtlasEnt.Type).Elem()
+	// Get and configure a machine for the delegation.
+	delegate := _yieldUnmarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	if err := delegate.Reset(slab, mach.tmp_rv, delegateAtlasEnt.Type); err != nil {
+		return true, err
+	}
+	mach.delegate = delegate
+	mach.phase = unmarshalMachineUnionInlinePhase_delegate
+
+	// The delegate needs to see a map open as well.
+	//  If there was length info, it's now one less, since we ate the discriminator.
+	open := Token{Type: TMapOpen, Length: mach.expectLen}
+	if open.Length > 0 {
+		open.Length--
+	}
+	if _, err := mach.delegate.Step(driver, slab, &open); err != nil {
+		return true, err
+	}
+
+	// Replay any entries we buffered.
+	//  These go through the driver (which routes right back to us, then our delegate)
+	//  because the delegate may recurse, and if so, the driver has to route to those machines.
+	for i := range mach.buffer {
+		if _, err := driver.Step(&mach.buffer[i]); err != nil {
+			return true, err
+		}
+	}
+	mach.buffer = mach.buffer[:0]
+	return false, nil
+}
+
+func (mach *unmarshalMachineUnionInline) step_delegate(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	done, err = mach.delegate.Step(driver, slab, tok)
+	if done && err == nil {
+		mach.target_rv.Set(mach.tmp_rv)
+	}
+	return
+}
+
+// Appends a copy of the token to the buffer, for replay later.
+// Decoders may reuse their byte slices, so bytes tokens get their own copy.
+func appendTokenCopy(buf []Token, tok *Token) []Token {
+	buf = append(buf, *tok)
+	if tok.Type == TBytes {
+		last := &buf[len(buf)-1]
+		last.Bytes = append([]byte(nil), tok.Bytes...)
+	}
+	return buf
+}
+
+func (mach *unmarshalMachineUnionInline) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -107,3 +107,7 @@ func (mach *unmarshalMachineUnionKeyed) step_acceptMapClose(driver *Unmarshaller
 		return true, ErrMalformedTokenStream{tok.Type, "map close at end of union value"}
 	}
 }
+
+func (mach *unmarshalMachineUnionKeyed) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
@@ -0,0 +1,90 @@
+package obj
+
+import (
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type unmarshalMachineUnionKinded struct {
+	cfg *atlas.UnionKindedMorphism // set on initialization
+
+	target_rv reflect.Value
+	target_rt reflect.Type
+
+	tmp_rv   reflect.Value
+	delegate UnmarshalMachine // actual machine, once we've demuxed with the kind of the first token.
+}
+
+func (mach *unmarshalMachineUnionKinded) Reset(_ *unmarshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv
+	mach.target_rt = rt
+	mach.delegate = nil
+	return nil
+}
+
+func (mach *unmarshalMachineUnionKinded) Step(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	if mach.delegate == nil {
+		if err := mach.prepareDemux(slab, tok); err != nil {
+			return true, err
+		}
+	}
+	done, err = mach.delegate.Step(driver, slab, tok)
+	if done && err == nil {
+		mach.target_rv.Set(mach.tmp_rv)
+	}
+	return
+}
+
+func (mach *unmarshalMachineUnionKinded) prepareDemux(slab *unmarshalSlab, tok *Token) error {
+	// Look up the configuration for this kind of token.
+	//  Signed and unsigned ints are interchangeable if only one was configured;
+	//  which one a decoder yields for positive numbers is a detail of the codec.
+	//  Likewise, big numbers are only yielded when the smaller types won't do,
+	//  so a member for big numbers also takes the smaller ones.
+	//  Numbers kept as text go to whichever member their value fits.
+	tt := tok.Type
+	if tt == TNumber {
+		normalized := *tok
+		if err := NormalizeNumber(&normalized); err != nil {
+			return err
+		}
+		tt = normalized.Type
+	}
+	delegate_rt, ok := mach.cfg.Elements[tt]
+	if !ok {
+		switch tt {
+		case TInt:
+			delegate_rt, ok = mach.cfg.Elements[TUint]
+		case TUint:
+			delegate_rt, ok = mach.cfg.Elements[TInt]
+		}
+	}
+	if !ok {
+		switch tt {
+		case TInt, TUint:
+			delegate_rt, ok = mach.cfg.Elements[TBigInt]
+		case TFloat64:
+			delegate_rt, ok = mach.cfg.Elements[TBigFloat]
+		}
+	}
+	if !ok {
+		return ErrNoSuchUnionKind{tt, mach.target_rt.String(), mach.cfg.KnownMembers}
+	}
+	// Allocate a new concrete value, and hang on to that rv handle.
+	//  Assigning into the interface must be done at the end if it's a non-pointer.
+	mach.tmp_rv = reflect.New(delegate_rt).Elem()
+	// Get and configure a machine for the delegation.
+	//  Members given without an entry get whatever machine their type would anywhere.
+	if delegateAtlasEnt := mach.cfg.Mappings[reflect.ValueOf(delegate_rt).Pointer()]; delegateAtlasEnt != nil {
+		mach.delegate = _yieldUnmarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	} else {
+		mach.delegate = _yieldUnmarshalMachinePtr(slab.tip(), slab.atlas, delegate_rt)
+	}
+	return mach.delegate.Reset(slab, mach.tmp_rv, delegate_rt)
+}
+
+func (mach *unmarshalMachineUnionKinded) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
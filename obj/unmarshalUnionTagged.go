@@ -0,0 +1,60 @@
+package obj
+
+import (
+	"reflect"
+
+	"github.com/polydawn/refmt/obj/atlas"
+	. "github.com/polydawn/refmt/tok"
+)
+
+type unmarshalMachineUnionTagged struct {
+	cfg *atlas.UnionTaggedMorphism // set on initialization
+
+	target_rv reflect.Value
+	target_rt reflect.Type
+
+	tmp_rv   reflect.Value
+	delegate UnmarshalMachine // actual machine, once we've demuxed with the tag on the first token.
+}
+
+func (mach *unmarshalMachineUnionTagged) Reset(_ *unmarshalSlab, rv reflect.Value, rt reflect.Type) error {
+	mach.target_rv = rv
+	mach.target_rt = rt
+	mach.delegate = nil
+	return nil
+}
+
+func (mach *unmarshalMachineUnionTagged) Step(driver *Unmarshaller, slab *unmarshalSlab, tok *Token) (done bool, err error) {
+	if mach.delegate == nil {
+		if err := mach.prepareDemux(slab, tok); err != nil {
+			return true, err
+		}
+	}
+	done, err = mach.delegate.Step(driver, slab, tok)
+	if done && err == nil {
+		mach.target_rv.Set(mach.tmp_rv)
+	}
+	return
+}
+
+func (mach *unmarshalMachineUnionTagged) prepareDemux(slab *unmarshalSlab, tok *Token) error {
+	// Look up the configuration for this tag.
+	// REVIEW: is an untagged TNull perhaps conditionally acceptable?
+	if !tok.Tagged {
+		return ErrNoSuchUnionTag{false, 0, mach.target_rt.String(), mach.cfg.KnownTags}
+	}
+	delegateAtlasEnt, ok := mach.cfg.Elements[tok.Tag]
+	if !ok {
+		return ErrNoSuchUnionTag{true, tok.Tag, mach.target_rt.String(), mach.cfg.KnownTags}
+	}
+	// Allocate a new concrete value, and hang on to that rv handle.
+	//  Assigning into the interface must be done at the end if it's a non-pointer.
+	mach.tmp_rv = reflect.New(delegateAtlasEnt.Type).Elem()
+	// Get and configure a machine for the delegation.
+	mach.delegate = _yieldUnmarshalMachinePtrForAtlasEntry(slab.tip(), delegateAtlasEnt, slab.atlas)
+	return mach.delegate.Reset(slab, mach.tmp_rv, delegateAtlasEnt.Type)
+}
+
+func (mach *unmarshalMachineUnionTagged) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
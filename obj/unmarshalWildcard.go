@@ -110,3 +110,7 @@ func (mach *unmarshalMachineWildcard) prepareDemux(driver *Unmarshaller, slab *u
 		return delegateMach.Step(driver, slab, tok)
 	}
 }
+
+func (mach *unmarshalMachineWildcard) appendPathStep(path []byte) []byte {
+	return appendPathStep(mach.delegate, path)
+}
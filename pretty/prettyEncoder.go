@@ -82,7 +82,7 @@ func (d *Encoder) Step(tok *Token) (done bool, err error) {
 			return true, fmt.Errorf("unexpected arrClose; expected start of key or end of map")
 		default:
 			switch tok.Type {
-			case TString, TInt, TUint:
+			case TString, TInt, TUint, TBigInt:
 				d.wr.Write(indentWord(len(d.stack)))
 				d.emitValue(tok)
 				d.wr.Write(wordColon)
@@ -232,6 +232,12 @@ func (d *Encoder) emitValue(tok *Token) {
 	case TFloat64:
 		b := strconv.AppendFloat(d.scratch[:0], tok.Float64, 'f', 6, 64)
 		d.wr.Write(b)
+	case TBigInt:
+		d.wr.Write(tok.BigInt.Append(d.scratch[:0], 10))
+	case TBigFloat:
+		d.wr.Write(tok.BigFloat.Append(d.scratch[:0], 'g', -1))
+	case TNumber:
+		d.wr.Write([]byte(tok.Str))
 	default:
 		panic(fmt.Errorf("TODO finish more pretty.Encoder primitives support: unhandled token %s", tok))
 	}
@@ -3,6 +3,7 @@ package refmt_test
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/polydawn/refmt"
@@ -36,6 +37,11 @@ func TestRoundTrip(t *testing.T) {
 	t.Run("4-value map[string]interface{str|int}", func(t *testing.T) {
 		testRoundTripAllEncodings(t, map[string]interface{}{"k": "v", "a": "b", "z": 26, "m": 9}, atlas.MustBuild())
 	})
+	t.Run("map[string]interface{big numbers}", func(t *testing.T) {
+		huge := new(big.Int).Lsh(big.NewInt(1), 70)
+		vast, _, _ := big.ParseFloat("1e400", 10, 64, big.ToNearestEven)
+		testRoundTripAllEncodings(t, map[string]interface{}{"pos": huge, "neg": new(big.Int).Neg(huge), "f": vast}, atlas.MustBuild())
+	})
 	t.Run("cbor tagging and str-str transform", func(t *testing.T) {
 		type Taggery string
 		roundTrip(t,
@@ -0,0 +1,18 @@
+/*
+	Package selector picks values out of a token stream by path,
+	without unmarshalling -- or even buffering -- the rest of the stream.
+
+	Paths are written like `.a.b[2].c`: `.name` selects a map entry by key,
+	`[2]` selects an array entry by index, and `["some key"]` selects a map
+	entry by a key that's not a plain name.  The wildcards `.*` and `[*]`
+	select every entry of a map or array, respectively.  Maps may also have
+	integer keys (as in cbor); those entries are only reached by `.*`.
+	(This is the same syntax as the paths reported in errors from
+	`refmt/obj`.)  An empty path selects the whole value.
+
+	A `Selector` wraps any TokenSource -- for example a json or cbor
+	Decoder -- and is a TokenSource itself, yielding each matching value
+	as a complete token stream of its own.  Everything else is skipped
+	as it's read, with no allocations.
+*/
+package selector
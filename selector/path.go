@@ -0,0 +1,156 @@
+package selector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Path is a parsed path expression: one Segment per step from the root.
+type Path []Segment
+
+type Segment struct {
+	Kind  SegmentKind
+	Key   string // Set for SegmentKind_Key.
+	Index int    // Set for SegmentKind_Index.
+}
+
+type SegmentKind uint8
+
+const (
+	SegmentKind_Key      SegmentKind = iota // `.name` or `["name"]`: selects a map entry.
+	SegmentKind_Index                       // `[2]`: selects an array entry.
+	SegmentKind_AnyKey                      // `.*`: selects every map entry.
+	SegmentKind_AnyIndex                    // `[*]`: selects every array entry.
+)
+
+// ErrInvalidPath is returned by Parse when a path expression is malformed.
+type ErrInvalidPath struct {
+	Expr   string // The whole path expression.
+	Offset int    // The offset within Expr where the problem was found.
+	Reason string
+}
+
+func (e ErrInvalidPath) Error() string {
+	return fmt.Sprintf("invalid path %q at offset %d: %s", e.Expr, e.Offset, e.Reason)
+}
+
+// Parse a path expression, like `.a.b[2].c`.
+func Parse(expr string) (Path, error) {
+	var path Path
+	if expr == "." {
+		return path, nil
+	}
+	for i := 0; i < len(expr); {
+		switch expr[i] {
+		case '.':
+			end := i + 1
+			for end < len(expr) && expr[end] != '.' && expr[end] != '[' {
+				end++
+			}
+			switch name := expr[i+1 : end]; name {
+			case "":
+				return nil, ErrInvalidPath{expr, i, "expected a name after '.'"}
+			case "*":
+				path = append(path, Segment{Kind: SegmentKind_AnyKey})
+			default:
+				path = append(path, Segment{Kind: SegmentKind_Key, Key: name})
+			}
+			i = end
+		case '[':
+			seg, end, err := parseBracket(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, seg)
+			i = end
+		default:
+			return nil, ErrInvalidPath{expr, i, "expected '.' or '['"}
+		}
+	}
+	return path, nil
+}
+
+// Parses a bracketed segment starting at expr[i]; returns the offset just after it.
+func parseBracket(expr string, i int) (Segment, int, error) {
+	rest := expr[i+1:]
+	if strings.HasPrefix(rest, `"`) {
+		// Scan for the closing quote, minding escapes.
+		for j := 1; j < len(rest); j++ {
+			switch rest[j] {
+			case '\\':
+				j++
+			case '"':
+				key, err := strconv.Unquote(rest[:j+1])
+				if err != nil {
+					return Segment{}, 0, ErrInvalidPath{expr, i + 1, "invalid quoted key"}
+				}
+				if j+1 >= len(rest) || rest[j+1] != ']' {
+					return Segment{}, 0, ErrInvalidPath{expr, i + 1 + j + 1, "expected ']'"}
+				}
+				return Segment{Kind: SegmentKind_Key, Key: key}, i + 1 + j + 2, nil
+			}
+		}
+		return Segment{}, 0, ErrInvalidPath{expr, i + 1, "unterminated quoted key"}
+	}
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return Segment{}, 0, ErrInvalidPath{expr, i, "expected ']'"}
+	}
+	inner := rest[:end]
+	if inner == "*" {
+		return Segment{Kind: SegmentKind_AnyIndex}, i + 1 + end + 1, nil
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil || n < 0 || inner[0] == '+' {
+		return Segment{}, 0, ErrInvalidPath{expr, i + 1, "expected an index, '*', or a quoted key"}
+	}
+	return Segment{Kind: SegmentKind_Index, Index: n}, i + 1 + end + 1, nil
+}
+
+// MustParse is like Parse, but panics if the expression is invalid.
+func MustParse(expr string) Path {
+	path, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// String returns the path expression, in canonical form.
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "."
+	}
+	var sb strings.Builder
+	for _, seg := range p {
+		sb.WriteString(seg.String())
+	}
+	return sb.String()
+}
+
+func (seg Segment) String() string {
+	switch seg.Kind {
+	case SegmentKind_Key:
+		if isPlainName(seg.Key) {
+			return "." + seg.Key
+		}
+		return "[" + strconv.Quote(seg.Key) + "]"
+	case SegmentKind_Index:
+		return "[" + strconv.Itoa(seg.Index) + "]"
+	case SegmentKind_AnyKey:
+		return ".*"
+	case SegmentKind_AnyIndex:
+		return "[*]"
+	default:
+		panic("invalid segment kind")
+	}
+}
+
+// Whether a key can be written as `.name`, and parse back the same.
+func isPlainName(s string) bool {
+	if s == "" || s == "*" {
+		return false
+	}
+	return !strings.ContainsAny(s, `.["`)
+}
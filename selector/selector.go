@@ -0,0 +1,196 @@
+package selector
+
+import (
+	"io"
+
+	"github.com/polydawn/refmt/shared"
+	. "github.com/polydawn/refmt/tok"
+)
+
+var _ shared.TokenSource = &Selector{}
+
+/*
+	Selector is a TokenSource which yields the values matching a Path
+	from another TokenSource.
+
+	Each match is yielded as a complete value: Step reports done at the end
+	of each one.  Once the end of the source value has been reached, Step
+	returns io.EOF.  So, to handle all the matches, run a TokenPump (or
+	just Step) repeatedly until io.EOF.
+
+	The tokens yielded are the same ones the source yielded, so any bytes
+	in them may be reused by the source, just as if it was used directly.
+*/
+type Selector struct {
+	path Path
+	src  shared.TokenSource
+
+	stack     []selectorFrame // Containers on the route to matches (never deeper than the path).
+	emitDepth int             // If inside a match: the depth of nesting within it.
+	skipDepth int             // If inside a value that can't contain matches: the depth of nesting within it.
+	srcDone   bool            // Whether the source has finished its value.
+	count     int             // Number of tokens read from the source for this value.
+}
+
+type selectorFrame struct {
+	isMap  bool
+	atKey  bool   // Whether a key (or the end of the map) is next.
+	key    string // If a map: the key of the current entry.
+	intKey bool   // If a map: whether the key of the current entry is an int (so `key` is unused).
+	index  int    // If an array: the index of the current entry.
+}
+
+func NewSelector(path Path, src shared.TokenSource) *Selector {
+	return &Selector{path: path, src: src}
+}
+
+// Reset the selector so it'll look for matches in another value from its source.
+// (This is useful if the source yields a series of values.)
+func (s *Selector) Reset() {
+	s.stack = s.stack[:0]
+	s.emitDepth = 0
+	s.skipDepth = 0
+	s.srcDone = false
+	s.count = 0
+}
+
+func (s *Selector) Step(tok *Token) (done bool, err error) {
+	for {
+		if s.srcDone {
+			return true, io.EOF
+		}
+		s.srcDone, err = s.src.Step(tok)
+		if err != nil {
+			return true, err
+		}
+		s.count++
+
+		// If inside a match: yield everything until we leave it.
+		if s.emitDepth > 0 {
+			s.emitDepth += nestingDelta(tok)
+			if s.emitDepth > 0 {
+				return false, nil
+			}
+			s.valueDone()
+			return true, nil
+		}
+
+		// If inside a value that can't match: skip everything until we leave it.
+		if s.skipDepth > 0 {
+			s.skipDepth += nestingDelta(tok)
+			if s.skipDepth == 0 {
+				s.valueDone()
+			}
+			continue
+		}
+
+		// Track keys and the ends of containers.
+		if n := len(s.stack); n > 0 {
+			frame := &s.stack[n-1]
+			if frame.isMap && frame.atKey {
+				switch tok.Type {
+				case TMapClose:
+					s.pop()
+				case TString:
+					frame.key = tok.Str
+					frame.intKey = false
+					frame.atKey = false
+				case TInt, TUint:
+					frame.intKey = true // Valid, but never matches a key segment.
+					frame.atKey = false
+				default:
+					return true, s.fail(tok, "map key or end of map")
+				}
+				continue
+			}
+			if !frame.isMap && tok.Type == TArrClose {
+				s.pop()
+				continue
+			}
+		}
+
+		// It's the start of a value.  Does its path match?
+		depth := len(s.stack)
+		if depth > 0 && !s.stack[depth-1].matches(s.path[depth-1]) {
+			s.skipValue(tok)
+			continue
+		}
+		if depth == len(s.path) {
+			switch tok.Type {
+			case TMapOpen, TArrOpen:
+				s.emitDepth = 1
+				return false, nil
+			case TMapClose, TArrClose:
+				return true, s.fail(tok, "start of value")
+			}
+			s.valueDone()
+			return true, nil
+		}
+		switch tok.Type {
+		case TMapOpen:
+			s.stack = append(s.stack, selectorFrame{isMap: true, atKey: true})
+		case TArrOpen:
+			s.stack = append(s.stack, selectorFrame{})
+		case TMapClose, TArrClose:
+			return true, s.fail(tok, "start of value")
+		default:
+			s.valueDone() // A scalar, but the path goes deeper; no match.
+		}
+	}
+}
+
+func (frame selectorFrame) matches(seg Segment) bool {
+	switch seg.Kind {
+	case SegmentKind_Key:
+		return frame.isMap && !frame.intKey && frame.key == seg.Key
+	case SegmentKind_Index:
+		return !frame.isMap && frame.index == seg.Index
+	case SegmentKind_AnyKey:
+		return frame.isMap
+	case SegmentKind_AnyIndex:
+		return !frame.isMap
+	default:
+		return false
+	}
+}
+
+func (s *Selector) skipValue(tok *Token) {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		s.skipDepth = 1
+	default:
+		s.valueDone()
+	}
+}
+
+func (s *Selector) fail(tok *Token, expected string) error {
+	return ErrInvalidStream{Got: *tok, Index: s.count - 1, Expected: expected}
+}
+
+func (s *Selector) pop() {
+	s.stack = s.stack[:len(s.stack)-1]
+	s.valueDone()
+}
+
+// Called after each complete value, to advance the position in the parent.
+func (s *Selector) valueDone() {
+	if n := len(s.stack); n > 0 {
+		frame := &s.stack[n-1]
+		if frame.isMap {
+			frame.atKey = true
+		} else {
+			frame.index++
+		}
+	}
+}
+
+func nestingDelta(tok *Token) int {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		return 1
+	case TMapClose, TArrClose:
+		return -1
+	default:
+		return 0
+	}
+}
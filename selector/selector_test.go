@@ -0,0 +1,125 @@
+package selector_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/polydawn/refmt/cbor"
+	"github.com/polydawn/refmt/json"
+	. "github.com/polydawn/refmt/selector"
+	"github.com/polydawn/refmt/shared"
+	. "github.com/polydawn/refmt/tok"
+)
+
+const document = `{
+	"a": {"b": [{"c": 1}, {"c": {"d": [true]}}, {"e": 3}]},
+	"skip": {"deep": [[[{"c": "no"}]]]},
+	"with space": "x",
+	"z": [1, 2]
+}`
+
+// Runs the selector over the document, and returns each match re-encoded as json.
+func selectAll(t *testing.T, expr string) []string {
+	t.Helper()
+	return selectFrom(t, expr, json.NewDecoder(json.DecodeOptions{}, bytes.NewBufferString(document)))
+}
+
+func selectFrom(t *testing.T, expr string, src shared.TokenSource) []string {
+	t.Helper()
+	sel := NewSelector(MustParse(expr), src)
+	var results []string
+	for {
+		var buf bytes.Buffer
+		err := shared.TokenPump{sel, json.NewEncoder(&buf, json.EncodeOptions{})}.Run()
+		if err == io.EOF {
+			return results
+		}
+		Wish(t, err, ShouldEqual, nil)
+		if err != nil {
+			return results
+		}
+		results = append(results, buf.String())
+	}
+}
+
+func TestSelector(t *testing.T) {
+	t.Run("root", func(t *testing.T) {
+		Wish(t, len(selectAll(t, ".")), ShouldEqual, 1)
+	})
+	t.Run("deep path", func(t *testing.T) {
+		Wish(t, selectAll(t, ".a.b[1].c"), ShouldEqual, []string{`{"d":[true]}`})
+		Wish(t, selectAll(t, ".a.b[1].c.d[0]"), ShouldEqual, []string{`true`})
+	})
+	t.Run("wildcards", func(t *testing.T) {
+		Wish(t, selectAll(t, ".a.b[*].c"), ShouldEqual, []string{`1`, `{"d":[true]}`})
+		Wish(t, selectAll(t, ".*[*]"), ShouldEqual, []string{`1`, `2`})
+	})
+	t.Run("quoted key", func(t *testing.T) {
+		Wish(t, selectAll(t, `["with space"]`), ShouldEqual, []string{`"x"`})
+	})
+	t.Run("int keys", func(t *testing.T) {
+		// {1: "x", "a": 2, "": {2: 3}} in cbor.
+		const serial = "\xa3\x01\x61x\x61a\x02\x60\xa1\x02\x03"
+		cborSrc := func() shared.TokenSource {
+			return cbor.NewDecoder(cbor.DecodeOptions{}, bytes.NewBufferString(serial))
+		}
+		Wish(t, selectFrom(t, ".a", cborSrc()), ShouldEqual, []string{`2`})
+		Wish(t, selectFrom(t, `[""].*`, cborSrc()), ShouldEqual, []string{`3`})
+		Wish(t, selectFrom(t, `[""]["2"]`, cborSrc()), ShouldEqual, []string(nil))
+	})
+	t.Run("no matches", func(t *testing.T) {
+		Wish(t, selectAll(t, ".nope"), ShouldEqual, []string(nil))
+		Wish(t, selectAll(t, ".z.c"), ShouldEqual, []string(nil))
+		Wish(t, selectAll(t, ".a.b[9]"), ShouldEqual, []string(nil))
+		Wish(t, selectAll(t, `.with space.x`), ShouldEqual, []string(nil))
+	})
+}
+
+func TestSelectorRejectsMalformedStream(t *testing.T) {
+	var buf shared.TokenBuffer
+	for _, tok := range []Token{
+		{Type: TMapOpen, Length: -1},
+		TokStr("a"), TokInt(1),
+		{Type: TBool, Bool: true}, TokInt(2),
+		{Type: TMapClose},
+	} {
+		buf.Step(&tok)
+	}
+	var tok Token
+	_, err := NewSelector(MustParse(".b"), buf.Replay()).Step(&tok)
+	Wish(t, err, ShouldEqual, ErrInvalidStream{Got: Token{Type: TBool, Bool: true}, Index: 3, Expected: "map key or end of map"})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("roundtrip", func(t *testing.T) {
+		for _, expr := range []string{".", ".a.b[2].c", ".*[*]", `["a.b"][0]`, `[""]`} {
+			Wish(t, MustParse(expr).String(), ShouldEqual, expr)
+		}
+	})
+	t.Run("segments", func(t *testing.T) {
+		Wish(t, MustParse(`.a[2]["q\"k"].*[*]`), ShouldEqual, Path{
+			{Kind: SegmentKind_Key, Key: "a"},
+			{Kind: SegmentKind_Index, Index: 2},
+			{Kind: SegmentKind_Key, Key: `q"k`},
+			{Kind: SegmentKind_AnyKey},
+			{Kind: SegmentKind_AnyIndex},
+		})
+	})
+	t.Run("errors", func(t *testing.T) {
+		for expr, expect := range map[string]ErrInvalidPath{
+			"a":      {"a", 0, "expected '.' or '['"},
+			".a..b":  {".a..b", 2, "expected a name after '.'"},
+			".a[2":   {".a[2", 2, "expected ']'"},
+			".a[-1]": {".a[-1]", 3, "expected an index, '*', or a quoted key"},
+			`["a"`:   {`["a"`, 4, "expected ']'"},
+			`["a]`:   {`["a]`, 1, "unterminated quoted key"},
+			`.a[x]`:  {`.a[x]`, 3, "expected an index, '*', or a quoted key"},
+		} {
+			_, err := Parse(expr)
+			Wish(t, err, ShouldEqual, expect)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package shared
+
+import (
+	"io"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+var (
+	_ TokenSink   = &TokenBuffer{}
+	_ TokenSource = &TokenReplayer{}
+)
+
+/*
+	TokenBuffer is a TokenSink which records every token it's given,
+	so they can be replayed later (any number of times) by a TokenReplayer.
+
+	Tokens are deep-copied as they're recorded: the content of every
+	Bytes token is copied into a single shared slab, so the decoders'
+	habit of reusing their byte slices is harmless, and recording doesn't
+	cost an allocation per token.
+
+	Like other sinks, Step reports done when a complete value has been
+	recorded; but it's fine to keep stepping afterwards, and record
+	several values back to back.  The replayer will report done at the
+	end of each value in turn, just as the original source did.
+*/
+type TokenBuffer struct {
+	toks  []Token // Recorded tokens; TBytes tokens have their Bytes field nil'd (see `slab`).
+	slab  []byte  // Content of all the TBytes tokens, concatenated.
+	ends  []int   // End offset in `slab` of each TBytes token's content, in order.
+	depth int     // Depth of nesting of maps and arrays, while recording.
+}
+
+// Step records a token.
+func (b *TokenBuffer) Step(tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		b.depth++
+	case TMapClose, TArrClose:
+		if b.depth == 0 {
+			return true, ErrInvalidStream{Got: *tok, Index: len(b.toks), Expected: "start of value"}
+		}
+		b.depth--
+	}
+	b.toks = append(b.toks, *tok)
+	if tok.Type == TBytes {
+		b.toks[len(b.toks)-1].Bytes = nil
+		b.slab = append(b.slab, tok.Bytes...)
+		b.ends = append(b.ends, len(b.slab))
+	}
+	return b.depth == 0, nil
+}
+
+// Len returns the number of tokens recorded.
+func (b *TokenBuffer) Len() int {
+	return len(b.toks)
+}
+
+// Reset discards all recorded tokens, retaining the memory for reuse.
+// Any TokenReplayers for this buffer must not be used afterwards.
+func (b *TokenBuffer) Reset() {
+	b.toks = b.toks[:0]
+	b.slab = b.slab[:0]
+	b.ends = b.ends[:0]
+	b.depth = 0
+}
+
+// Replay returns a TokenSource which yields the recorded tokens, from the start.
+// Recording more tokens into the buffer while replaying is fine;
+// the replayer will see them once it gets there.
+func (b *TokenBuffer) Replay() *TokenReplayer {
+	return &TokenReplayer{buf: b}
+}
+
+/*
+	TokenReplayer is a TokenSource which yields the tokens recorded in a
+	TokenBuffer.  Get one by calling `TokenBuffer.Replay`.
+
+	It reports done at the end of each complete value, and returns
+	io.EOF if stepped after all of the recorded tokens have been yielded.
+
+	The Bytes of yielded tokens point into the buffer's memory:
+	they must not be modified, and are only valid until the buffer is Reset.
+*/
+type TokenReplayer struct {
+	buf   *TokenBuffer
+	i     int // Index of the next token to yield.
+	bi    int // Index into `buf.ends` of the next TBytes token.
+	depth int // Depth of nesting of maps and arrays.
+}
+
+func (r *TokenReplayer) Step(tok *Token) (done bool, err error) {
+	if r.i >= len(r.buf.toks) {
+		return true, io.EOF
+	}
+	*tok = r.buf.toks[r.i]
+	r.i++
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		r.depth++
+	case TMapClose, TArrClose:
+		r.depth--
+	case TBytes:
+		start := 0
+		if r.bi > 0 {
+			start = r.buf.ends[r.bi-1]
+		}
+		end := r.buf.ends[r.bi]
+		tok.Bytes = r.buf.slab[start:end:end]
+		r.bi++
+	}
+	return r.depth == 0, nil
+}
+
+// More returns whether there are any tokens left to replay.
+func (r *TokenReplayer) More() bool {
+	return r.i < len(r.buf.toks)
+}
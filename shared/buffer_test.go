@@ -0,0 +1,79 @@
+package shared_test
+
+import (
+	"io"
+	"testing"
+
+	. "github.com/polydawn/refmt/shared"
+	. "github.com/polydawn/refmt/testutil"
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func TestTokenBufferRoundtripsFixtures(t *testing.T) {
+	var buf TokenBuffer
+	for _, seq := range fixtures.Sequences {
+		switch seq.Title {
+		case "empty", "dangling arr open":
+			continue // These fixtures are deliberately incomplete.
+		}
+		buf.Reset()
+		var done bool
+		for i := range seq.Tokens {
+			var err error
+			done, err = buf.Step(&seq.Tokens[i])
+			Assert(t, "record error for "+seq.Title, nil, err)
+		}
+		Assert(t, "record done for "+seq.Title, true, done)
+
+		// Replay twice, to be sure a buffer is reusable for several passes.
+		for pass := 0; pass < 2; pass++ {
+			replay := buf.Replay()
+			for i := range seq.Tokens {
+				var tok Token
+				done, err := replay.Step(&tok)
+				Assert(t, "replay error for "+seq.Title, nil, err)
+				Assert(t, "replay token for "+seq.Title, true, IsTokenEqual(seq.Tokens[i], tok))
+				Assert(t, "replay done for "+seq.Title, i == len(seq.Tokens)-1, done)
+			}
+			Assert(t, "replay more for "+seq.Title, false, replay.More())
+		}
+	}
+}
+
+func TestTokenBufferCopiesBytes(t *testing.T) {
+	var buf TokenBuffer
+	tok := Token{Type: TBytes, Bytes: []byte("abc")}
+	buf.Step(&tok)
+	tok.Bytes[0] = 'z'
+	tok.Bytes = []byte("de")
+	buf.Step(&tok)
+
+	replay := buf.Replay()
+	replay.Step(&tok)
+	Assert(t, "first bytes", "abc", string(tok.Bytes))
+	replay.Step(&tok)
+	Assert(t, "second bytes", "de", string(tok.Bytes))
+	_, err := replay.Step(&tok)
+	Assert(t, "end of replay", io.EOF, err)
+}
+
+func TestTokenBufferInPump(t *testing.T) {
+	seq := fixtures.SequenceMap["maps nested in maps with mixed nulls"].Tokens
+	var buf TokenBuffer
+	for i := range seq {
+		buf.Step(&seq[i])
+	}
+	var copied TokenBuffer
+	err := TokenPump{buf.Replay(), &copied}.Run()
+	Assert(t, "pump error", nil, err)
+	Assert(t, "pumped length", len(seq), copied.Len())
+}
+
+func TestTokenBufferRejectsUnbalancedClose(t *testing.T) {
+	var buf TokenBuffer
+	buf.Step(&Token{Type: TArrOpen, Length: -1})
+	buf.Step(&Token{Type: TArrClose})
+	_, err := buf.Step(&Token{Type: TMapClose})
+	Assert(t, "error", ErrInvalidStream{Got: Token{Type: TMapClose}, Index: 2, Expected: "start of value"}, err)
+}
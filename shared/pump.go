@@ -18,6 +18,35 @@ type TokenSink interface {
 	Step(consume *Token) (done bool, err error)
 }
 
+/*
+	A TokenSource which can say where in its input the token it most
+	recently yielded began.  The json and cbor decoders are both
+	PositionalTokenSources.
+*/
+type PositionalTokenSource interface {
+	TokenSource
+	TokenOffset() int
+}
+
+/*
+	ErrAtOffset wraps an error raised by a TokenSink (typically an
+	unmarshal error, like `obj.ErrNoSuchField`) with the byte offset in the
+	input of the token that caused it.  TokenPump wraps sink errors this
+	way whenever its source is a PositionalTokenSource.
+*/
+type ErrAtOffset struct {
+	Offset int
+	Err    error
+}
+
+func (e ErrAtOffset) Error() string {
+	return fmt.Sprintf("%s (at byte offset %d)", e.Err, e.Offset)
+}
+
+func (e ErrAtOffset) Unwrap() error {
+	return e.Err
+}
+
 type TokenPump struct {
 	TokenSource
 	TokenSink
@@ -34,6 +63,9 @@ func (p TokenPump) Run() error {
 		}
 		sinkDone, err = p.TokenSink.Step(&tok)
 		if err != nil {
+			if src, ok := p.TokenSource.(PositionalTokenSource); ok {
+				return ErrAtOffset{src.TokenOffset(), err}
+			}
 			return err
 		}
 		if srcDone {
@@ -44,3 +76,42 @@ func (p TokenPump) Run() error {
 		}
 	}
 }
+
+/*
+	ValidatingTokenSource wraps another TokenSource, checking (with a
+	`tok.Validator`) that the tokens it yields form a well-formed stream,
+	and that it reports done exactly when the value is complete.
+
+	This is mostly useful for testing custom token sources -- for example,
+	pump a `ValidatingTokenSource` wrapping your source into a sink that
+	discards everything, and you'll know your source yields tokens that
+	any encoder will accept.
+*/
+type ValidatingTokenSource struct {
+	TokenSource
+	validator Validator
+	count     int // Number of tokens yielded so far.
+}
+
+func NewValidatingTokenSource(src TokenSource) *ValidatingTokenSource {
+	return &ValidatingTokenSource{TokenSource: src}
+}
+
+func (s *ValidatingTokenSource) Step(tok *Token) (done bool, err error) {
+	done, err = s.TokenSource.Step(tok)
+	if err != nil {
+		return true, err
+	}
+	s.count++
+	validDone, err := s.validator.Step(tok)
+	if err != nil {
+		return true, err
+	}
+	switch {
+	case done && !validDone:
+		return true, ErrInvalidStream{Got: *tok, Index: s.count - 1, Expected: "more tokens (the source reported done, but the value is incomplete)"}
+	case !done && validDone:
+		return true, ErrInvalidStream{Got: *tok, Index: s.count - 1, Expected: "the source to report done (the value is complete)"}
+	}
+	return done, nil
+}
@@ -0,0 +1,55 @@
+package shared_test
+
+import (
+	"testing"
+
+	. "github.com/polydawn/refmt/shared"
+	. "github.com/polydawn/refmt/testutil"
+	. "github.com/polydawn/refmt/tok"
+)
+
+// A TokenSource which yields a fixed list of tokens, reporting done
+// exactly where it's told to, so it can get that wrong on purpose.
+type scriptedSource struct {
+	toks []Token
+	done []bool
+	i    int
+}
+
+func (s *scriptedSource) Step(tok *Token) (done bool, err error) {
+	*tok = s.toks[s.i]
+	s.i++
+	return s.done[s.i-1], nil
+}
+
+func runValidating(src *scriptedSource) (steps int, err error) {
+	vsrc := NewValidatingTokenSource(src)
+	for {
+		var tok Token
+		done, err := vsrc.Step(&tok)
+		steps++
+		if done || err != nil {
+			return steps, err
+		}
+	}
+}
+
+func TestValidatingTokenSource(t *testing.T) {
+	arr := []Token{{Type: TArrOpen, Length: 1}, TokInt(1), {Type: TArrClose}}
+
+	steps, err := runValidating(&scriptedSource{toks: arr, done: []bool{false, false, true}})
+	Assert(t, "well-behaved source error", nil, err)
+	Assert(t, "well-behaved source steps", 3, steps)
+
+	steps, err = runValidating(&scriptedSource{toks: arr, done: []bool{false, true, true}})
+	Assert(t, "done too early error", ErrInvalidStream{Got: TokInt(1), Index: 1, Expected: "more tokens (the source reported done, but the value is incomplete)"}, err)
+	Assert(t, "done too early steps", 2, steps)
+
+	steps, err = runValidating(&scriptedSource{toks: append(arr, TokInt(2)), done: []bool{false, false, false, true}})
+	Assert(t, "not done when complete error", ErrInvalidStream{Got: Token{Type: TArrClose}, Index: 2, Expected: "the source to report done (the value is complete)"}, err)
+	Assert(t, "not done when complete steps", 3, steps)
+
+	steps, err = runValidating(&scriptedSource{toks: []Token{{Type: TArrClose}}, done: []bool{true}})
+	Assert(t, "malformed stream error", ErrInvalidStream{Got: Token{Type: TArrClose}, Index: 0, Expected: "start of value"}, err)
+	Assert(t, "malformed stream steps", 1, steps)
+}
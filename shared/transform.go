@@ -0,0 +1,278 @@
+package shared
+
+import (
+	"fmt"
+
+	. "github.com/polydawn/refmt/tok"
+)
+
+/*
+	A TokenTransformer is a stage in a token stream pipeline: it consumes
+	tokens one at a time, and for each, appends any number of tokens
+	(including none) to `out`, returning the extended slice.
+
+	Transformers are stateful, and see every token of the stream in order;
+	they're responsible for tracking whatever nesting they need to.
+	(The transformers in this package all do so with very little memory,
+	so streams are never materialized.)
+
+	Use `NewTransformingTokenSource` to apply transformers to a TokenSource.
+*/
+type TokenTransformer interface {
+	Transform(tok *Token, out []Token) ([]Token, error)
+}
+
+/*
+	NewTransformingTokenSource wraps a TokenSource so that its tokens
+	are run through each of the given transformers in turn.
+
+	The resulting source reports done at the end of each value, as usual.
+	It's an error for the transformers to reduce an entire value to nothing.
+
+	Tokens are yielded as soon as the transformers produce them, so the
+	wrapped source is consumed no faster than it must be; but note that
+	bytes in yielded tokens may be reused by the wrapped source, just as
+	if it was used directly.
+*/
+func NewTransformingTokenSource(src TokenSource, stages ...TokenTransformer) TokenSource {
+	for _, stage := range stages {
+		src = &transformingTokenSource{src: src, tr: stage}
+	}
+	return src
+}
+
+type transformingTokenSource struct {
+	src     TokenSource
+	tr      TokenTransformer
+	queue   []Token // Tokens produced by the transformer which we haven't yielded yet.
+	qi      int     // Index of next token to yield from the queue.
+	srcDone bool    // Whether the source has finished the current value.
+}
+
+func (s *transformingTokenSource) Step(tok *Token) (done bool, err error) {
+	for s.qi >= len(s.queue) {
+		if s.srcDone {
+			s.srcDone = false
+			return true, fmt.Errorf("token transformer emitted nothing for the value")
+		}
+		s.queue = s.queue[:0]
+		s.qi = 0
+		var next Token
+		s.srcDone, err = s.src.Step(&next)
+		if err != nil {
+			return true, err
+		}
+		s.queue, err = s.tr.Transform(&next, s.queue)
+		if err != nil {
+			return true, err
+		}
+	}
+	*tok = s.queue[s.qi]
+	s.qi++
+	if s.srcDone && s.qi == len(s.queue) {
+		s.srcDone = false
+		return true, nil
+	}
+	return false, nil
+}
+
+/*
+	FilterKeys returns a TokenTransformer which drops map entries --
+	the key, and the whole value, however deeply nested -- for which
+	`keep` returns false.  Maps at any depth are affected.
+	Entries with integer keys (which cbor allows) are passed through
+	untouched, here and by RenameKeys and ReplaceValues alike.
+
+	Since the number of entries dropped from a map can't be known in
+	advance, all maps are emitted with undeclared length (-1).
+*/
+func FilterKeys(keep func(key string) bool) TokenTransformer {
+	return &keyedTransformer{
+		dropsEntries: true,
+		onKey: func(key string) (string, keyAction, Token) {
+			if keep(key) {
+				return key, keyAction_keep, Token{}
+			}
+			return key, keyAction_drop, Token{}
+		},
+	}
+}
+
+/*
+	RenameKeys returns a TokenTransformer which replaces every map key
+	(in maps at any depth) with the result of `rename`.
+	Take care not to create repeated keys.
+*/
+func RenameKeys(rename func(key string) string) TokenTransformer {
+	return &keyedTransformer{
+		onKey: func(key string) (string, keyAction, Token) {
+			return rename(key), keyAction_keep, Token{}
+		},
+	}
+}
+
+/*
+	ReplaceValues returns a TokenTransformer which, for map entries where
+	`replace` returns true, discards the value -- however deeply nested --
+	and emits the returned token in its place.  The token must be a scalar.
+	Maps at any depth are affected.
+
+	This is useful for redaction; for example:
+
+		shared.ReplaceValues(func(key string) (tok.Token, bool) {
+			return tok.Token{Type: tok.TString, Str: "[redacted]"}, key == "password"
+		})
+*/
+func ReplaceValues(replace func(key string) (Token, bool)) TokenTransformer {
+	return &keyedTransformer{
+		onKey: func(key string) (string, keyAction, Token) {
+			if replacement, ok := replace(key); ok {
+				return key, keyAction_replace, replacement
+			}
+			return key, keyAction_keep, Token{}
+		},
+	}
+}
+
+/*
+	RewriteScalars returns a TokenTransformer which calls `rewrite` on every
+	scalar token which is a value (map keys are not affected), and emits the
+	token as modified.  The token must still be a scalar afterwards.
+*/
+func RewriteScalars(rewrite func(tok *Token) error) TokenTransformer {
+	return &keyedTransformer{onScalar: rewrite}
+}
+
+type keyAction uint8
+
+const (
+	keyAction_keep    keyAction = iota // Emit the (possibly renamed) key, and the value.
+	keyAction_drop                     // Emit neither the key nor the value.
+	keyAction_replace                  // Emit the key, and a replacement instead of the value.
+)
+
+// keyedTransformer implements all the transformers above: it tracks nesting
+// so it knows which tokens are map keys, and can skip whole values.
+type keyedTransformer struct {
+	onKey        func(key string) (newKey string, action keyAction, replacement Token) // optional.
+	onScalar     func(tok *Token) error                                                // optional.
+	dropsEntries bool                                                                  // if true, emit maps with undeclared length.
+
+	frames      []keyedFrame // One per open map or array.
+	skipDepth   int          // If skipping a value: the depth of nesting within it.
+	action      keyAction    // What to do with the next value.
+	replacement Token        // The replacement for the next value, if action is replace.
+	count       int          // Number of tokens seen so far.
+}
+
+type keyedFrame struct {
+	isMap bool // Whether it's a map (otherwise an array).
+	atKey bool // Whether a key (or the end of the map) is next.
+}
+
+func (t *keyedTransformer) Transform(tok *Token, out []Token) ([]Token, error) {
+	t.count++
+
+	// If skipping a value: swallow tokens until we've left it.
+	if t.skipDepth > 0 {
+		switch tok.Type {
+		case TMapOpen, TArrOpen:
+			t.skipDepth++
+		case TMapClose, TArrClose:
+			t.skipDepth--
+			if t.skipDepth == 0 {
+				t.valueDone()
+			}
+		}
+		return out, nil
+	}
+
+	// If expecting a map key: consult about what to do with the entry.
+	if n := len(t.frames); n > 0 && t.frames[n-1].atKey {
+		switch tok.Type {
+		case TMapClose:
+			t.pop()
+			return append(out, *tok), nil
+		case TString:
+			t.frames[n-1].atKey = false
+			if t.onKey == nil {
+				t.action = keyAction_keep
+				return append(out, *tok), nil
+			}
+			var newKey string
+			newKey, t.action, t.replacement = t.onKey(tok.Str)
+			if t.action == keyAction_drop {
+				return out, nil
+			}
+			key := *tok
+			key.Str = newKey
+			return append(out, key), nil
+		case TInt, TUint:
+			// Int keys (as in cbor) have no name to consult about; keep the entry.
+			t.frames[n-1].atKey = false
+			t.action = keyAction_keep
+			return append(out, *tok), nil
+		default:
+			return out, t.fail(tok, "map key or end of map")
+		}
+	}
+
+	// Otherwise, it's a value (or the end of an array).
+	if tok.Type == TArrClose {
+		t.pop()
+		return append(out, *tok), nil
+	}
+	action := t.action
+	t.action = keyAction_keep
+	switch action {
+	case keyAction_replace:
+		out = append(out, t.replacement)
+		fallthrough
+	case keyAction_drop:
+		switch tok.Type {
+		case TMapOpen, TArrOpen:
+			t.skipDepth = 1
+		default:
+			t.valueDone()
+		}
+		return out, nil
+	}
+	switch tok.Type {
+	case TMapOpen:
+		t.frames = append(t.frames, keyedFrame{isMap: true, atKey: true})
+		out = append(out, *tok)
+		if t.dropsEntries {
+			out[len(out)-1].Length = -1
+		}
+		return out, nil
+	case TArrOpen:
+		t.frames = append(t.frames, keyedFrame{})
+		return append(out, *tok), nil
+	case TMapClose:
+		return out, t.fail(tok, "start of value")
+	}
+	out = append(out, *tok)
+	if t.onScalar != nil {
+		if err := t.onScalar(&out[len(out)-1]); err != nil {
+			return out, err
+		}
+	}
+	t.valueDone()
+	return out, nil
+}
+
+func (t *keyedTransformer) fail(tok *Token, expected string) error {
+	return ErrInvalidStream{Got: *tok, Index: t.count - 1, Expected: expected}
+}
+
+func (t *keyedTransformer) pop() {
+	t.frames = t.frames[:len(t.frames)-1]
+	t.valueDone()
+}
+
+// Called after each complete value: if it was a map entry's value, a key is next.
+func (t *keyedTransformer) valueDone() {
+	if n := len(t.frames); n > 0 && t.frames[n-1].isMap {
+		t.frames[n-1].atKey = true
+	}
+}
@@ -0,0 +1,126 @@
+package shared_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/polydawn/refmt/shared"
+	. "github.com/polydawn/refmt/testutil"
+	. "github.com/polydawn/refmt/tok"
+)
+
+// {"user": "a", "secret": {"k": [1, 2]}, "list": [{"secret": "x", "n": 1}]}
+var transformFixture = []Token{
+	{Type: TMapOpen, Length: 3},
+	TokStr("user"), TokStr("a"),
+	TokStr("secret"), {Type: TMapOpen, Length: 1},
+	TokStr("k"), {Type: TArrOpen, Length: 2}, TokInt(1), TokInt(2), {Type: TArrClose},
+	{Type: TMapClose},
+	TokStr("list"), {Type: TArrOpen, Length: 1},
+	{Type: TMapOpen, Length: 2}, TokStr("secret"), TokStr("x"), TokStr("n"), TokInt(1), {Type: TMapClose},
+	{Type: TArrClose},
+	{Type: TMapClose},
+}
+
+func runTransform(t *testing.T, stages ...TokenTransformer) []Token {
+	t.Helper()
+	return runTransformOn(t, transformFixture, stages...)
+}
+
+func runTransformOn(t *testing.T, in []Token, stages ...TokenTransformer) []Token {
+	t.Helper()
+	var buf TokenBuffer
+	for i := range in {
+		buf.Step(&in[i])
+	}
+	var result TokenBuffer
+	err := TokenPump{NewTransformingTokenSource(buf.Replay(), stages...), &result}.Run()
+	Assert(t, "pump error", nil, err)
+	var toks []Token
+	replay := result.Replay()
+	for replay.More() {
+		var tok Token
+		replay.Step(&tok)
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+func TestFilterKeys(t *testing.T) {
+	toks := runTransform(t, FilterKeys(func(key string) bool { return key != "secret" }))
+	Assert(t, "filtered", []Token{
+		{Type: TMapOpen, Length: -1},
+		TokStr("user"), TokStr("a"),
+		TokStr("list"), {Type: TArrOpen, Length: 1},
+		{Type: TMapOpen, Length: -1}, TokStr("n"), TokInt(1), {Type: TMapClose},
+		{Type: TArrClose},
+		{Type: TMapClose},
+	}, toks)
+}
+
+func TestRenameKeys(t *testing.T) {
+	toks := runTransform(t, RenameKeys(strings.ToUpper))
+	Assert(t, "renamed", []Token{
+		{Type: TMapOpen, Length: 3},
+		TokStr("USER"), TokStr("a"),
+		TokStr("SECRET"), {Type: TMapOpen, Length: 1},
+		TokStr("K"), {Type: TArrOpen, Length: 2}, TokInt(1), TokInt(2), {Type: TArrClose},
+		{Type: TMapClose},
+		TokStr("LIST"), {Type: TArrOpen, Length: 1},
+		{Type: TMapOpen, Length: 2}, TokStr("SECRET"), TokStr("x"), TokStr("N"), TokInt(1), {Type: TMapClose},
+		{Type: TArrClose},
+		{Type: TMapClose},
+	}, toks)
+}
+
+func TestReplaceValuesAndRewriteScalars(t *testing.T) {
+	toks := runTransform(t,
+		ReplaceValues(func(key string) (Token, bool) {
+			return TokStr("[redacted]"), key == "secret"
+		}),
+		RewriteScalars(func(tok *Token) error {
+			if tok.Type == TInt {
+				tok.Int *= 10
+			}
+			return nil
+		}),
+	)
+	Assert(t, "replaced", []Token{
+		{Type: TMapOpen, Length: 3},
+		TokStr("user"), TokStr("a"),
+		TokStr("secret"), TokStr("[redacted]"),
+		TokStr("list"), {Type: TArrOpen, Length: 1},
+		{Type: TMapOpen, Length: 2}, TokStr("secret"), TokStr("[redacted]"), TokStr("n"), TokInt(10), {Type: TMapClose},
+		{Type: TArrClose},
+		{Type: TMapClose},
+	}, toks)
+}
+
+func TestTransformRejectsMalformedStream(t *testing.T) {
+	tr := RenameKeys(strings.ToUpper)
+	_, err := tr.Transform(&Token{Type: TMapOpen, Length: -1}, nil)
+	Assert(t, "map open", nil, err)
+	_, err = tr.Transform(&Token{Type: TBool, Bool: true}, nil)
+	Assert(t, "bool key", ErrInvalidStream{Got: Token{Type: TBool, Bool: true}, Index: 1, Expected: "map key or end of map"}, err)
+}
+
+func TestTransformPassesIntKeys(t *testing.T) {
+	// {1: {"secret": 2}, "secret": 3, 4: 5} -- int keys aren't consulted about, but their values are still transformed.
+	in := []Token{
+		{Type: TMapOpen, Length: 3},
+		TokInt(1), {Type: TMapOpen, Length: 1}, TokStr("secret"), TokInt(2), {Type: TMapClose},
+		TokStr("secret"), TokInt(3),
+		{Type: TUint, Uint: 4}, TokInt(5),
+		{Type: TMapClose},
+	}
+	toks := runTransformOn(t, in,
+		FilterKeys(func(key string) bool { return key != "secret" }),
+		RenameKeys(strings.ToUpper),
+	)
+	Assert(t, "transformed", []Token{
+		{Type: TMapOpen, Length: -1},
+		TokInt(1), {Type: TMapOpen, Length: -1}, {Type: TMapClose},
+		{Type: TUint, Uint: 4}, TokInt(5),
+		{Type: TMapClose},
+	}, toks)
+}
@@ -0,0 +1,163 @@
+package fixtures
+
+import (
+	. "github.com/polydawn/refmt/tok"
+)
+
+/*
+	ConformanceCase is a JSON document which a decoder must either accept or reject.
+
+	These are in the style of (and largely borrowed from) JSONTestSuite:
+	names starting with "y_" are documents a conformant parser must accept,
+	and those cases list the tokens the document should yield;
+	names starting with "n_" are documents it must reject, and have no tokens.
+
+	Unlike the rest of this package, these are specific to JSON, since
+	they're about serial text rather than token sequences; they're kept
+	here with the other fixtures so any decoder claiming to speak JSON
+	can be held to them.  The json package runs them with Strict set.
+*/
+type ConformanceCase struct {
+	Name   string
+	Input  string
+	Tokens Tokens
+}
+
+// Reports whether the case is one a conformant parser must accept.
+func (c ConformanceCase) Accept() bool {
+	return c.Name[0] == 'y'
+}
+
+var JsonConformance = []ConformanceCase{
+	// Structure.
+	{"y_structure_lonely_null", `null`, Tokens{{Type: TNull}}},
+	{"y_structure_lonely_true", `true`, Tokens{{Type: TBool, Bool: true}}},
+	{"y_structure_lonely_false", `false`, Tokens{{Type: TBool, Bool: false}}},
+	{"y_structure_lonely_int", `42`, Tokens{{Type: TInt, Int: 42}}},
+	{"y_structure_lonely_string", `"asd"`, Tokens{TokStr("asd")}},
+	{"y_structure_whitespace_array", " \t\r\n[]\n\r\t ", Tokens{{Type: TArrOpen, Length: -1}, {Type: TArrClose}}},
+	{"y_structure_true_in_array", `[true]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TBool, Bool: true}, {Type: TArrClose}}},
+	{"n_structure_no_data", ``, nil},
+	{"n_structure_whitespace_only", " \n ", nil},
+	{"n_structure_double_array", `[][]`, nil},
+	{"n_structure_array_trailing_garbage", `[1]x`, nil},
+	{"n_structure_object_trailing_garbage", `{"a":true} "x"`, nil},
+	{"n_structure_number_with_trailing_garbage", `2@`, nil},
+	{"n_structure_unclosed_array", `[1`, nil},
+	{"n_structure_unclosed_object", `{"asd":"asd"`, nil},
+	{"n_structure_close_unopened_array", `1]`, nil},
+	{"n_structure_angle_bracket", `<.>`, nil},
+	{"n_structure_single_star", `*`, nil},
+	{"n_structure_capitalized_True", `[True]`, nil},
+	{"n_structure_null_byte_outside_string", "[\x00]", nil},
+	{"n_structure_ascii_unicode_identifier", `aå`, nil},
+
+	// Literals.
+	{"n_incomplete_null", `[nul]`, nil},
+	{"n_incomplete_false", `[fals]`, nil},
+	{"n_incomplete_true", `[tru]`, nil},
+	{"n_misspelled_null", `nope`, nil},
+	{"n_misspelled_true", `trap`, nil},
+	{"n_misspelled_false", `fails`, nil},
+	{"n_literal_truncated_at_end", `nu`, nil},
+	{"n_literal_with_trailing_garbage", `truex`, nil},
+
+	// Arrays.
+	{"y_array_empty", `[]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TArrClose}}},
+	{"y_array_heterogeneous", `[null, 1, "1", {}]`, Tokens{
+		{Type: TArrOpen, Length: -1},
+		{Type: TNull},
+		{Type: TInt, Int: 1},
+		TokStr("1"),
+		{Type: TMapOpen, Length: -1},
+		{Type: TMapClose},
+		{Type: TArrClose},
+	}},
+	{"y_array_nested", `[[[]]]`, Tokens{
+		{Type: TArrOpen, Length: -1},
+		{Type: TArrOpen, Length: -1},
+		{Type: TArrOpen, Length: -1},
+		{Type: TArrClose},
+		{Type: TArrClose},
+		{Type: TArrClose},
+	}},
+	{"n_array_extra_comma", `["",]`, nil},
+	{"n_array_number_and_comma", `[1,]`, nil},
+	{"n_array_comma_only", `[,]`, nil},
+	{"n_array_leading_comma", `[,1]`, nil},
+	{"n_array_double_comma", `[1,,2]`, nil},
+	{"n_array_missing_value_separator", `[1 2]`, nil},
+	{"n_array_colon_instead_of_comma", `["": 1]`, nil},
+	{"n_array_extra_close", `["x"]]`, nil},
+
+	// Objects.
+	{"y_object_empty", `{}`, Tokens{{Type: TMapOpen, Length: -1}, {Type: TMapClose}}},
+	{"y_object_basic", `{"asd":"sdf", "dfg":"fgh"}`, Tokens{
+		{Type: TMapOpen, Length: -1},
+		TokStr("asd"), TokStr("sdf"),
+		TokStr("dfg"), TokStr("fgh"),
+		{Type: TMapClose},
+	}},
+	{"y_object_empty_key", `{"":0}`, Tokens{{Type: TMapOpen, Length: -1}, TokStr(""), {Type: TInt, Int: 0}, {Type: TMapClose}}},
+	{"n_object_trailing_comma", `{"id":0,}`, nil},
+	{"n_object_comma_instead_of_colon", `{"x", null}`, nil},
+	{"n_object_missing_colon", `{"a" b}`, nil},
+	{"n_object_missing_value", `{"a":}`, nil},
+	{"n_object_non_string_key", `{1:1}`, nil},
+	{"n_object_unquoted_key", `{a: "b"}`, nil},
+	{"n_object_single_quote", `{'a':0}`, nil},
+	{"n_object_null_key", `{null:null}`, nil},
+	{"n_object_array_key", `{[]:1}`, nil},
+
+	// Numbers.
+	{"y_number_zero", `[0]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TInt, Int: 0}, {Type: TArrClose}}},
+	{"y_number_negative_zero", `[-0]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TInt, Int: 0}, {Type: TArrClose}}},
+	{"y_number_negative_int", `[-123]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TInt, Int: -123}, {Type: TArrClose}}},
+	{"y_number_simple_real", `[123.456789]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TFloat64, Float64: 123.456789}, {Type: TArrClose}}},
+	{"y_number_real_capital_e", `[1E22]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TFloat64, Float64: 1e22}, {Type: TArrClose}}},
+	{"y_number_real_neg_exp", `[1e-2]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TFloat64, Float64: 0.01}, {Type: TArrClose}}},
+	{"y_number_real_pos_exponent", `[1e+2]`, Tokens{{Type: TArrOpen, Length: -1}, {Type: TFloat64, Float64: 100}, {Type: TArrClose}}},
+	{"y_number_zero_fraction", `0.5`, Tokens{{Type: TFloat64, Float64: 0.5}}},
+	{"n_number_leading_zero", `[012]`, nil},
+	{"n_number_negative_leading_zero", `[-012]`, nil},
+	{"n_number_lonely_leading_zero", `01`, nil},
+	{"n_number_plus_one", `[+1]`, nil},
+	{"n_number_minus_only", `[-]`, nil},
+	{"n_number_minus_at_end", `-`, nil},
+	{"n_number_minus_space", `[- 1]`, nil},
+	{"n_number_dot_only", `[.]`, nil},
+	{"n_number_starting_with_dot", `[.123]`, nil},
+	{"n_number_trailing_dot", `[1.]`, nil},
+	{"n_number_trailing_dot_at_end", `1.`, nil},
+	{"n_number_dot_then_exponent", `[1.e1]`, nil},
+	{"n_number_exponent_without_digits", `[1e]`, nil},
+	{"n_number_exponent_sign_without_digits", `[1e+]`, nil},
+	{"n_number_exponent_at_end", `1e-`, nil},
+	{"n_number_hex", `[0x1]`, nil},
+	{"n_number_infinity", `[Infinity]`, nil},
+	{"n_number_minus_infinity", `[-Infinity]`, nil},
+	{"n_number_nan", `[NaN]`, nil},
+	{"n_number_two_dots", `[1.2.3]`, nil},
+
+	// Strings.
+	{"y_string_escapes", `["\"\\\/\b\f\n\r\t"]`, Tokens{{Type: TArrOpen, Length: -1}, TokStr("\"\\/\b\f\n\r\t"), {Type: TArrClose}}},
+	{"y_string_unicode_escape", `["a\u30af"]`, Tokens{{Type: TArrOpen, Length: -1}, TokStr("aク"), {Type: TArrClose}}},
+	{"y_string_surrogate_pair", `["\ud834\udd1e"]`, Tokens{{Type: TArrOpen, Length: -1}, TokStr("𝄞"), {Type: TArrClose}}},
+	{"y_string_utf8", `["€𝄞"]`, Tokens{{Type: TArrOpen, Length: -1}, TokStr("€𝄞"), {Type: TArrClose}}},
+	{"y_string_del_unescaped", "[\"a\x7fa\"]", Tokens{{Type: TArrOpen, Length: -1}, TokStr("a\x7fa"), {Type: TArrClose}}},
+	{"n_string_unescaped_newline", "[\"new\nline\"]", nil},
+	{"n_string_unescaped_tab", "[\"\t\"]", nil},
+	{"n_string_unescaped_ctrl_char", "[\"a\x00a\"]", nil},
+	{"n_string_invalid_escape", `["\x00"]`, nil},
+	{"n_string_escaped_emoji", `["\🌀"]`, nil},
+	{"n_string_incomplete_unicode_escape", `["\u00A"]`, nil},
+	{"n_string_invalid_unicode_escape", `["\uqqqq"]`, nil},
+	{"n_string_single_quote", `['single quote']`, nil},
+	{"n_string_no_quotes", `[abc]`, nil},
+	{"n_string_unterminated", `["a`, nil},
+	{"n_string_invalid_utf8", "[\"\xff\"]", nil},
+	{"n_string_lone_continuation_byte", "[\"\x81\"]", nil},
+	{"n_string_truncated_utf8", "[\"\xe6\x97\"]", nil},
+	{"n_string_overlong_utf8", "[\"\xc0\xaf\"]", nil},
+	{"n_string_utf16_surrogate_as_utf8", "[\"\xed\xa0\x80\"]", nil},
+}
@@ -0,0 +1,182 @@
+package tok
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/*
+	Number is a numeric literal, kept as the text it was written as.
+
+	Decoders which are asked to preserve numbers exactly yield TNumber tokens
+	carrying this text, instead of deciding up front whether the number is an
+	int or a float (and possibly losing something in the conversion:
+	`1.0` would otherwise come back out as `1`, and a float with more than
+	seventeen significant digits would be rounded).
+	Unmarshalling a TNumber into an `interface{}` produces a Number;
+	the conversion methods on it can then be used as needed.
+
+	The text follows the JSON grammar for numbers: an optional minus sign,
+	integer digits without leading zeros, an optional fraction, and an
+	optional exponent.  Use IsValid to check this.
+*/
+type Number string
+
+func (n Number) String() string {
+	return string(n)
+}
+
+// Returns the number as an int64, or an error if it's not an integer or is out of range.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// Returns the number as a uint64, or an error if it's not a non-negative integer or is out of range.
+func (n Number) Uint64() (uint64, error) {
+	return strconv.ParseUint(string(n), 10, 64)
+}
+
+// Returns the number as the nearest float64, or an error if it's out of range.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Returns the number as a big.Int, or an error if it's not an integer.
+func (n Number) BigInt() (*big.Int, error) {
+	if !n.IsValid() {
+		return nil, ErrInvalidNumber{string(n)}
+	}
+	bi, ok := new(big.Int).SetString(string(n), 10)
+	if !ok {
+		return nil, &strconv.NumError{Func: "BigInt", Num: string(n), Err: strconv.ErrSyntax}
+	}
+	return bi, nil
+}
+
+// Returns the number as a big.Float, with enough precision to hold every digit written.
+func (n Number) BigFloat() (*big.Float, error) {
+	if !n.IsValid() {
+		return nil, ErrInvalidNumber{string(n)}
+	}
+	// Roughly four bits per decimal digit; more would be fiction.
+	bf, _, err := big.ParseFloat(string(n), 10, uint(len(n))*4, big.ToNearestEven)
+	return bf, err
+}
+
+// Reports whether the text is a number per the JSON grammar.
+func (n Number) IsValid() bool {
+	s := string(n)
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i == len(s):
+		return false
+	case s[i] == '0':
+		i++
+	case '1' <= s[i] && s[i] <= '9':
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		j := skipDigits(s, i+1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		j := skipDigits(s, i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(s)
+}
+
+// Reports whether any digit before the exponent isn't zero:
+// if so, a float parsed from the text can only be zero by underflowing.
+func hasNonzeroMantissa(s string) bool {
+	for i := 0; i < len(s) && s[i] != 'e' && s[i] != 'E'; i++ {
+		if '1' <= s[i] && s[i] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+/*
+	Converts a TNumber token into whichever other numeric token type fits its value.
+	Tokens of any other type are left untouched.
+
+	An integer is yielded as TInt if it fits; otherwise TUint, if it fits;
+	otherwise TBigInt.  Anything with a fraction or exponent is yielded as
+	TFloat64, unless it's out of the range of a float64 -- too big, or so
+	small it would round to zero -- in which case it's yielded as TBigFloat.
+*/
+func NormalizeNumber(tok *Token) error {
+	if tok.Type != TNumber {
+		return nil
+	}
+	s := tok.Str
+	if !Number(s).IsValid() {
+		return ErrInvalidNumber{s}
+	}
+	tok.Str = ""
+	if strings.IndexAny(s, ".eE") < 0 {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			tok.Type = TInt
+			tok.Int = i
+			return nil
+		}
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			tok.Type = TUint
+			tok.Uint = u
+			return nil
+		}
+		tok.Type = TBigInt
+		tok.BigInt, _ = new(big.Int).SetString(s, 10) // Can't fail: already validated.
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil && (f != 0 || !hasNonzeroMantissa(s)) {
+		tok.Type = TFloat64
+		tok.Float64 = f
+		return nil
+	} else if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+		return err
+	}
+	// Either too big for a float64, or so small it rounded to zero.
+	bf, err := Number(s).BigFloat()
+	if err != nil {
+		return err
+	}
+	tok.Type = TBigFloat
+	tok.BigFloat = bf
+	return nil
+}
+
+// ErrInvalidNumber is the error returned when a Number's text isn't a valid number.
+type ErrInvalidNumber struct {
+	Text string
+}
+
+func (e ErrInvalidNumber) Error() string {
+	return fmt.Sprintf("invalid number literal %q", e.Text)
+}
@@ -0,0 +1,67 @@
+package tok
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	. "github.com/polydawn/refmt/testutil"
+)
+
+func TestNumberValidity(t *testing.T) {
+	for _, tr := range []struct {
+		text  string
+		valid bool
+	}{
+		{"0", true},
+		{"-0", true},
+		{"1.0", true},
+		{"-12.5e+3", true},
+		{"6E-7", true},
+		{"123456789012345678901234567890", true},
+		{"", false},
+		{"-", false},
+		{"01", false},
+		{"+1", false},
+		{".5", false},
+		{"1.", false},
+		{"1e", false},
+		{"1e+", false},
+		{"0x10", false},
+		{"Inf", false},
+		{"1 ", false},
+	} {
+		Assert(t, fmt.Sprintf("validity of %q", tr.text),
+			tr.valid, Number(tr.text).IsValid())
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	bigInt, _ := new(big.Int).SetString("18446744073709551616", 10)
+	bigFloat, _, _ := big.ParseFloat("1e400", 10, 20, big.ToNearestEven) // Precision of four bits per character of the text.
+	tinyFloat, _, _ := big.ParseFloat("-1e-400", 10, 28, big.ToNearestEven)
+	for _, tr := range []struct {
+		text   string
+		expect Token
+	}{
+		{"1", Token{Type: TInt, Int: 1}},
+		{"-1", Token{Type: TInt, Int: -1}},
+		{"1.0", Token{Type: TFloat64, Float64: 1}},
+		{"18446744073709551615", Token{Type: TUint, Uint: 18446744073709551615}},
+		{"18446744073709551616", Token{Type: TBigInt, BigInt: bigInt}},
+		{"1e400", Token{Type: TBigFloat, BigFloat: bigFloat}},
+		{"-1e-400", Token{Type: TBigFloat, BigFloat: tinyFloat}},
+		{"0.0e-400", Token{Type: TFloat64, Float64: 0}},
+	} {
+		tok := Token{Type: TNumber, Str: tr.text}
+		err := NormalizeNumber(&tok)
+		Assert(t, fmt.Sprintf("error normalizing %q", tr.text), nil, err)
+		Assert(t, fmt.Sprintf("normalizing %q yields %s", tr.text, tr.expect),
+			true, IsTokenEqual(tok, tr.expect))
+		Assert(t, fmt.Sprintf("normalizing %q clears text", tr.text), "", tok.Str)
+	}
+
+	tok := Token{Type: TNumber, Str: "+1"}
+	Assert(t, "normalizing an invalid number",
+		ErrInvalidNumber{"+1"}, NormalizeNumber(&tok))
+}
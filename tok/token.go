@@ -8,6 +8,7 @@ package tok
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 )
 
 type Token struct {
@@ -23,6 +24,9 @@ type Token struct {
 	Uint    uint64  // Value union.  Only one of these has meaning, depending on the value of 'Type'.
 	Float64 float64 // Value union.  Only one of these has meaning, depending on the value of 'Type'.
 
+	BigInt   *big.Int   // Value union.  Only one of these has meaning, depending on the value of 'Type'.  Treat as immutable.
+	BigFloat *big.Float // Value union.  Only one of these has meaning, depending on the value of 'Type'.  Treat as immutable.
+
 	Tagged bool // Extension slot for cbor.
 	Tag    int  // Extension slot for cbor.  Only applicable if tagged=true.
 }
@@ -42,6 +46,17 @@ const (
 	TInt     TokenType = 'i'
 	TUint    TokenType = 'u'
 	TFloat64 TokenType = 'f'
+
+	// Numbers which may not fit in the int64, uint64, or float64 types.
+	// Decoders yield these only when a number doesn't fit in the smaller types;
+	// encoders emit them in the smallest form their format offers.
+	TBigInt   TokenType = 'I'
+	TBigFloat TokenType = 'F'
+
+	// A number kept as the text it was written as (in the Str field).
+	// Decoders yield this only when asked to preserve numbers exactly;
+	// see the Number type.
+	TNumber TokenType = 'n'
 )
 
 func (tt TokenType) String() string {
@@ -68,13 +83,19 @@ func (tt TokenType) String() string {
 		return "uint"
 	case TFloat64:
 		return "float"
+	case TBigInt:
+		return "bigint"
+	case TBigFloat:
+		return "bigfloat"
+	case TNumber:
+		return "number"
 	}
 	return "invalid"
 }
 
 func (tt TokenType) IsValid() bool {
 	switch tt {
-	case TString, TBytes, TBool, TInt, TUint, TFloat64, TNull:
+	case TString, TBytes, TBool, TInt, TUint, TFloat64, TBigInt, TBigFloat, TNumber, TNull:
 		return true
 	case TMapOpen, TMapClose, TArrOpen, TArrClose:
 		return true
@@ -85,7 +106,7 @@ func (tt TokenType) IsValid() bool {
 
 func (tt TokenType) IsValue() bool {
 	switch tt {
-	case TString, TBytes, TBool, TInt, TUint, TFloat64:
+	case TString, TBytes, TBool, TInt, TUint, TFloat64, TBigInt, TBigFloat, TNumber:
 		return true
 	default:
 		return false
@@ -126,6 +147,12 @@ func IsTokenEqual(t1, t2 Token) bool {
 		return t1.Value() == t2.Value()
 	case TBytes:
 		return bytes.Equal(t1.Bytes, t2.Bytes)
+	case TBigInt:
+		return t1.BigInt.Cmp(t2.BigInt) == 0
+	case TBigFloat:
+		return t1.BigFloat.Cmp(t2.BigFloat) == 0
+	case TNumber:
+		return t1.Str == t2.Str
 	default:
 		return false
 	}
@@ -149,6 +176,12 @@ func (t Token) Value() interface{} {
 		return t.Uint
 	case TFloat64:
 		return t.Float64
+	case TBigInt:
+		return t.BigInt
+	case TBigFloat:
+		return t.BigFloat
+	case TNumber:
+		return Number(t.Str)
 	default:
 		return nil
 	}
@@ -46,6 +46,8 @@ func TestTokenEqualityDefn(t *testing.T) {
 		{Token{Type: TBool, Bool: true}, Token{Type: TBool, Bool: false}, false},
 		{Token{Type: TBytes, Bytes: []byte{1, 2, 3}}, Token{Type: TBytes, Bytes: []byte{1, 2, 3}}, true},
 		{Token{Type: TBytes, Bytes: []byte{1, 2, 3}}, Token{Type: TBytes, Bytes: []byte{4, 5, 0xff}}, false},
+		{Token{Type: TNumber, Str: "1.0"}, Token{Type: TNumber, Str: "1.0"}, true},
+		{Token{Type: TNumber, Str: "1.0"}, Token{Type: TNumber, Str: "1"}, false}, // numbers as text compare as text
 		{Token{Type: TInt, Int: 124}, Token{Type: TMapOpen}, false},
 		{Token{Type: TMapOpen}, Token{Type: TInt, Int: 124}, false},
 
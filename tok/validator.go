@@ -0,0 +1,139 @@
+package tok
+
+import (
+	"fmt"
+)
+
+/*
+	Validator checks that a sequence of tokens forms exactly one well-formed
+	value: maps and arrays are balanced, map keys are strings or ints,
+	map and array lengths (when declared) match the actual number of entries,
+	and nothing follows the end of the value.
+
+	Feed tokens to `Step` one at a time, exactly as a TokenSink would receive them;
+	`Step` returns done once a complete value has been seen, and an
+	`ErrInvalidStream` as soon as the stream goes wrong.
+	Call `Reset` to reuse the Validator for another value.
+
+	Validator is useful for checking the output of a custom TokenSource
+	(such as a hand-written marshal machine) independently of any encoder;
+	see also `shared.ValidatingTokenSource`.
+*/
+type Validator struct {
+	stack []validatorFrame
+	count int  // Number of tokens seen so far.
+	done  bool // Set when a complete value has been seen; any further tokens are an error.
+}
+
+type validatorFrame struct {
+	typ       TokenType // TMapOpen or TArrOpen.
+	length    int       // Declared length, or -1.
+	entries   int       // Number of entries seen so far.
+	expectKey bool      // Only for maps: whether the next token should be a key (or map close).
+}
+
+func (v *Validator) Reset() {
+	v.stack = v.stack[:0]
+	v.count = 0
+	v.done = false
+}
+
+func (v *Validator) Step(tok *Token) (done bool, err error) {
+	v.count++
+	if v.done {
+		return true, v.fail(tok, "nothing (the value is already complete)")
+	}
+	if !tok.Type.IsValid() {
+		return true, v.fail(tok, "a valid token type")
+	}
+	if len(v.stack) == 0 {
+		return v.stepValue(tok)
+	}
+	frame := &v.stack[len(v.stack)-1]
+	switch frame.typ {
+	case TMapOpen:
+		if frame.expectKey {
+			switch tok.Type {
+			case TMapClose:
+				return v.stepClose(tok, frame)
+			case TString, TInt, TUint:
+				if err := v.countEntry(tok, frame); err != nil {
+					return true, err
+				}
+				frame.expectKey = false
+				return false, nil
+			default:
+				return true, v.fail(tok, "map key or end of map")
+			}
+		}
+		frame.expectKey = true
+		return v.stepValue(tok)
+	case TArrOpen:
+		if tok.Type == TArrClose {
+			return v.stepClose(tok, frame)
+		}
+		if err := v.countEntry(tok, frame); err != nil {
+			return true, err
+		}
+		return v.stepValue(tok)
+	}
+	panic("unreachable")
+}
+
+func (v *Validator) stepValue(tok *Token) (done bool, err error) {
+	switch tok.Type {
+	case TMapOpen, TArrOpen:
+		if tok.Length < -1 {
+			return true, v.fail(tok, "a length of -1 (unknown) or greater")
+		}
+		v.stack = append(v.stack, validatorFrame{tok.Type, tok.Length, 0, true})
+		return false, nil
+	case TMapClose, TArrClose:
+		return true, v.fail(tok, "start of value")
+	}
+	return v.finishValue(), nil
+}
+
+func (v *Validator) stepClose(tok *Token, frame *validatorFrame) (done bool, err error) {
+	if frame.length >= 0 && frame.entries != frame.length {
+		return true, v.fail(tok, fmt.Sprintf("%d more entries (declared length was %d)", frame.length-frame.entries, frame.length))
+	}
+	v.stack = v.stack[:len(v.stack)-1]
+	return v.finishValue(), nil
+}
+
+func (v *Validator) countEntry(tok *Token, frame *validatorFrame) error {
+	frame.entries++
+	if frame.length >= 0 && frame.entries > frame.length {
+		if frame.typ == TMapOpen {
+			return v.fail(tok, fmt.Sprintf("end of map (declared length was %d)", frame.length))
+		}
+		return v.fail(tok, fmt.Sprintf("end of array (declared length was %d)", frame.length))
+	}
+	return nil
+}
+
+func (v *Validator) finishValue() bool {
+	if len(v.stack) == 0 {
+		v.done = true
+	}
+	return v.done
+}
+
+func (v *Validator) fail(tok *Token, expected string) error {
+	return ErrInvalidStream{*tok, v.count - 1, expected}
+}
+
+// ErrInvalidStream is the error returned when a token stream is not well-formed:
+// by Validator; by `shared.ValidatingTokenSource`, which also returns it when
+// its source reports done at the wrong point; and by the other token stream
+// helpers in `shared` and `selector`.
+type ErrInvalidStream struct {
+	Got      Token  // The token that was invalid.
+	Index    int    // The number of tokens that preceded this one in the stream.
+	Expected string // Freeform string describing what would have been valid instead.
+}
+
+func (e ErrInvalidStream) Error() string {
+	return fmt.Sprintf("invalid token stream: unexpected %s at token %d; expected %s", e.Got, e.Index, e.Expected)
+}
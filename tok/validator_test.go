@@ -0,0 +1,86 @@
+package tok_test
+
+import (
+	"testing"
+
+	. "github.com/polydawn/refmt/testutil"
+	. "github.com/polydawn/refmt/tok"
+	"github.com/polydawn/refmt/tok/fixtures"
+)
+
+func TestValidatorAcceptsFixtures(t *testing.T) {
+	var v Validator
+	for _, seq := range fixtures.Sequences {
+		switch seq.Title {
+		case "empty", "dangling arr open":
+			continue // These fixtures are deliberately incomplete.
+		}
+		for _, s := range []fixtures.Sequence{seq, seq.SansLengthInfo()} {
+			v.Reset()
+			var done bool
+			var err error
+			for i := range s.Tokens {
+				if done {
+					t.Errorf("validator reported done early for fixture %q", s.Title)
+					break
+				}
+				done, err = v.Step(&s.Tokens[i])
+				if err != nil {
+					t.Errorf("validator rejected fixture %q: %s", s.Title, err)
+					break
+				}
+			}
+			Assert(t, "validator done for "+s.Title, true, done)
+		}
+	}
+}
+
+func TestValidatorRejects(t *testing.T) {
+	tt := []struct {
+		title  string
+		tokens []Token
+		expect error
+	}{
+		{"unbalanced close",
+			[]Token{{Type: TArrOpen, Length: -1}, {Type: TMapClose}},
+			ErrInvalidStream{Token{Type: TMapClose}, 1, "start of value"}},
+		{"close at top level",
+			[]Token{{Type: TArrClose}},
+			ErrInvalidStream{Token{Type: TArrClose}, 0, "start of value"}},
+		{"map key not stringy",
+			[]Token{{Type: TMapOpen, Length: -1}, {Type: TBool, Bool: true}},
+			ErrInvalidStream{Token{Type: TBool, Bool: true}, 1, "map key or end of map"}},
+		{"map key composite",
+			[]Token{{Type: TMapOpen, Length: -1}, {Type: TArrOpen, Length: -1}},
+			ErrInvalidStream{Token{Type: TArrOpen, Length: -1}, 1, "map key or end of map"}},
+		{"map closed while expecting value",
+			[]Token{{Type: TMapOpen, Length: -1}, TokStr("k"), {Type: TMapClose}},
+			ErrInvalidStream{Token{Type: TMapClose}, 2, "start of value"}},
+		{"map longer than declared",
+			[]Token{{Type: TMapOpen, Length: 1}, TokStr("k"), TokInt(1), TokStr("k2")},
+			ErrInvalidStream{TokStr("k2"), 3, "end of map (declared length was 1)"}},
+		{"array shorter than declared",
+			[]Token{{Type: TArrOpen, Length: 2}, TokInt(1), {Type: TArrClose}},
+			ErrInvalidStream{Token{Type: TArrClose}, 2, "1 more entries (declared length was 2)"}},
+		{"array longer than declared",
+			[]Token{{Type: TArrOpen, Length: 1}, TokInt(1), TokInt(2)},
+			ErrInvalidStream{TokInt(2), 2, "end of array (declared length was 1)"}},
+		{"value after end",
+			[]Token{TokInt(1), TokInt(2)},
+			ErrInvalidStream{TokInt(2), 1, "nothing (the value is already complete)"}},
+		{"invalid token type",
+			[]Token{{}},
+			ErrInvalidStream{Token{}, 0, "a valid token type"}},
+	}
+	var v Validator
+	for _, tr := range tt {
+		v.Reset()
+		var err error
+		for i := range tr.tokens {
+			if _, err = v.Step(&tr.tokens[i]); err != nil {
+				break
+			}
+		}
+		Assert(t, tr.title, tr.expect, err)
+	}
+}
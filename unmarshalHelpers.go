@@ -15,7 +15,7 @@ type DecodeOptions interface {
 func Unmarshal(opts DecodeOptions, data []byte, v interface{}) error {
 	switch o2 := opts.(type) {
 	case json.DecodeOptions:
-		return json.Unmarshal(data, v)
+		return json.UnmarshalAtlased(o2, data, v, atlas.MustBuild())
 	case cbor.DecodeOptions:
 		return cbor.Unmarshal(o2, data, v)
 	default:
@@ -26,7 +26,7 @@ func Unmarshal(opts DecodeOptions, data []byte, v interface{}) error {
 func UnmarshalAtlased(opts DecodeOptions, data []byte, v interface{}, atl atlas.Atlas) error {
 	switch o2 := opts.(type) {
 	case json.DecodeOptions:
-		return json.UnmarshalAtlased(data, v, atl)
+		return json.UnmarshalAtlased(o2, data, v, atl)
 	case cbor.DecodeOptions:
 		return cbor.UnmarshalAtlased(o2, data, v, atl)
 	default:
@@ -41,7 +41,7 @@ type Unmarshaller interface {
 func NewUnmarshaller(opts DecodeOptions, r io.Reader) Unmarshaller {
 	switch o2 := opts.(type) {
 	case json.DecodeOptions:
-		return json.NewUnmarshaller(r)
+		return json.NewUnmarshallerAtlased(o2, r, atlas.MustBuild())
 	case cbor.DecodeOptions:
 		return cbor.NewUnmarshaller(o2, r)
 	default:
@@ -52,7 +52,7 @@ func NewUnmarshaller(opts DecodeOptions, r io.Reader) Unmarshaller {
 func NewUnmarshallerAtlased(opts DecodeOptions, r io.Reader, atl atlas.Atlas) Unmarshaller {
 	switch o2 := opts.(type) {
 	case json.DecodeOptions:
-		return json.NewUnmarshallerAtlased(r, atl)
+		return json.NewUnmarshallerAtlased(o2, r, atl)
 	case cbor.DecodeOptions:
 		return cbor.NewUnmarshallerAtlased(o2, r, atl)
 	default:
@@ -5,8 +5,11 @@ import (
 
 	. "github.com/smartystreets/goconvey/convey"
 
+	"github.com/polydawn/refmt/cbor"
 	"github.com/polydawn/refmt/json"
+	"github.com/polydawn/refmt/obj"
 	"github.com/polydawn/refmt/obj/atlas"
+	"github.com/polydawn/refmt/shared"
 )
 
 func TestUnmarshal(t *testing.T) {
@@ -38,12 +41,12 @@ func TestUnmarshal(t *testing.T) {
 				j    string
 				err  string
 			}{
-				{"trailing commas", `{"x":"1","y":"2",,,}`, "invalid char while expecting start of key: comma"},
-				{"just commas", `{,,,}`, "invalid char while expecting start of key: comma"},
-				{"leading commas", `{,,,"x":"1","y":"2",,,}`, "invalid char while expecting start of key: comma"},
-				{"no commas", `{"x":"1""y":"2"}`, "expected comma or map close after map value; got quote"},
-				{"no commas, just spaces", `{    "x":"1"    "y":"2"   }`, "expected comma or map close after map value; got quote"},
-				{"no commas, just tabs", `{	"x":"1"	"y":"2"	}`, "expected comma or map close after map value; got quote"},
+				{"trailing commas", `{"x":"1","y":"2",,,}`, "json: syntax error at line 1, column 18 (byte offset 17): invalid char while expecting start of key: comma"},
+				{"just commas", `{,,,}`, "json: syntax error at line 1, column 2 (byte offset 1): invalid char while expecting start of key: comma"},
+				{"leading commas", `{,,,"x":"1","y":"2",,,}`, "json: syntax error at line 1, column 2 (byte offset 1): invalid char while expecting start of key: comma"},
+				{"no commas", `{"x":"1""y":"2"}`, "json: syntax error at line 1, column 9 (byte offset 8): expected comma or map close after map value; got quote"},
+				{"no commas, just spaces", `{    "x":"1"    "y":"2"   }`, "json: syntax error at line 1, column 17 (byte offset 16): expected comma or map close after map value; got quote"},
+				{"no commas, just tabs", `{	"x":"1"	"y":"2"	}`, "json: syntax error at line 1, column 11 (byte offset 10): expected comma or map close after map value; got quote"},
 			} {
 				Convey(tc.name, func() {
 					var slot map[string]string
@@ -53,18 +56,28 @@ func TestUnmarshal(t *testing.T) {
 				})
 			}
 		})
+		Convey("lossless numbers", func() {
+			var slot interface{}
+			bs := []byte(`{"a":1.0,"b":[0.30000000000000000444,-0,1E+2],"c":123456789012345678901234567890}`)
+			err := Unmarshal(json.DecodeOptions{LosslessNumbers: true}, bs, &slot)
+			So(err, ShouldBeNil)
+			So(slot.(map[string]interface{})["a"], ShouldEqual, Number("1.0"))
+			out, err := Marshal(json.EncodeOptions{}, slot)
+			So(err, ShouldBeNil)
+			So(string(out), ShouldEqual, string(bs))
+		})
 		Convey("array comma handling errors", func() {
 			for _, tc := range []struct {
 				name string
 				j    string
 				err  string
 			}{
-				{"trailing commas", `["1","2",,,]`, "invalid char while expecting start of value: comma"},
-				{"just commas", `[,,,]`, "invalid char while expecting start of value: comma"},
-				{"leading commas", `[,,,"1","2",,,]`, "invalid char while expecting start of value: comma"},
-				{"no commas", `["1""2"]`, "expected comma or array close after array value; got quote"},
-				{"no commas, just spaces", `[    "1"    "2"   ]`, "expected comma or array close after array value; got quote"},
-				{"no commas, just tabs", `[	"1"	"2"	]`, "expected comma or array close after array value; got quote"},
+				{"trailing commas", `["1","2",,,]`, "json: syntax error at line 1, column 10 (byte offset 9): invalid char while expecting start of value: comma"},
+				{"just commas", `[,,,]`, "json: syntax error at line 1, column 2 (byte offset 1): invalid char while expecting start of value: comma"},
+				{"leading commas", `[,,,"1","2",,,]`, "json: syntax error at line 1, column 2 (byte offset 1): invalid char while expecting start of value: comma"},
+				{"no commas", `["1""2"]`, "json: syntax error at line 1, column 5 (byte offset 4): expected comma or array close after array value; got quote"},
+				{"no commas, just spaces", `[    "1"    "2"   ]`, "json: syntax error at line 1, column 13 (byte offset 12): expected comma or array close after array value; got quote"},
+				{"no commas, just tabs", `[	"1"	"2"	]`, "json: syntax error at line 1, column 7 (byte offset 6): expected comma or array close after array value; got quote"},
 			} {
 				Convey(tc.name, func() {
 					var slot []string
@@ -94,5 +107,62 @@ func TestUnmarshalAtlased(t *testing.T) {
 			err := UnmarshalAtlased(json.DecodeOptions{}, bs, &slot, atl)
 			So(err, ShouldBeNil)
 		})
+		Convey("obj errors report position", func() {
+			type testObj struct {
+				X string
+			}
+			var slot testObj
+			atl := atlas.MustBuild(
+				atlas.BuildEntry(testObj{}).
+					StructMap().Autogenerate().
+					Complete(),
+			)
+			bs := []byte(`{"x":"1",` + "\n" + `  "z":"2"}`)
+			err := UnmarshalAtlased(json.DecodeOptions{}, bs, &slot, atl)
+			So(err, ShouldResemble, shared.ErrAtOffset{12, obj.ErrNoSuchField{"z", "refmt.testObj"}})
+			So(err.Error(), ShouldEqual, `unmarshal error: stream contains key "z", but there's no such field in structs of type refmt.testObj (at byte offset 12)`)
+		})
+	})
+	Convey("json ignoring unknown fields", t, func() {
+		type testObj struct {
+			X string
+		}
+		var slot testObj
+		atl := atlas.MustBuild(
+			atlas.BuildEntry(testObj{}).
+				StructMap().Autogenerate().
+				Complete(),
+		)
+		bs := []byte(`{"z":{"deep":[1,{"x":"no"},[]]},"x":"1","w":[]}`)
+		err := UnmarshalAtlased(json.DecodeOptions{IgnoreUnknownFields: true}, bs, &slot, atl)
+		So(err, ShouldBeNil)
+		So(slot, ShouldResemble, testObj{"1"})
+	})
+	Convey("cbor", t, func() {
+		Convey("ignoring unknown fields", func() {
+			type testObj struct {
+				X string
+			}
+			var slot testObj
+			atl := atlas.MustBuild(
+				atlas.BuildEntry(testObj{}).
+					StructMap().Autogenerate().
+					Complete(),
+			)
+			// {"z": [_ 1, {}], "x": "1"}, with the array in indefinite-length form.
+			bs := []byte{0xa2, 0x61, 'z', 0x9f, 0x01, 0xa0, 0xff, 0x61, 'x', 0x61, '1'}
+			err := UnmarshalAtlased(cbor.DecodeOptions{IgnoreUnknownFields: true}, bs, &slot, atl)
+			So(err, ShouldBeNil)
+			So(slot, ShouldResemble, testObj{"1"})
+		})
+		Convey("obj errors report position", func() {
+			var slot map[string]string
+			bs := []byte{0xa1, 0x61, 'x', 0x01}
+			err := UnmarshalAtlased(cbor.DecodeOptions{}, bs, &slot, atlas.MustBuild())
+			So(err, ShouldHaveSameTypeAs, shared.ErrAtOffset{})
+			So(err.(shared.ErrAtOffset).Offset, ShouldEqual, 3)
+			So(err.(shared.ErrAtOffset).Err, ShouldHaveSameTypeAs, obj.ErrAtPath{})
+			So(err.(shared.ErrAtOffset).Err.(obj.ErrAtPath).Path, ShouldEqual, `["x"]`)
+		})
 	})
 }